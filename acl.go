@@ -0,0 +1,281 @@
+package cidr
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ACLAction is the action of an ACL rule
+type ACLAction int
+
+const (
+	ACLDeny   = ACLAction(0)
+	ACLPermit = ACLAction(1)
+)
+
+// String returns "deny" or "permit"
+func (a ACLAction) String() string {
+	switch a {
+	case ACLDeny:
+		return "deny"
+	case ACLPermit:
+		return "permit"
+	}
+	return fmt.Sprintf("ACLAction(%d)", int(a))
+}
+
+// ACLRule is a rule of an ordered ACL, matching packets from any of Src to any of Dst.
+// 	An empty Src or Dst matches any IP.
+type ACLRule struct {
+	Action ACLAction
+	Src    []*CIDR
+	Dst    []*CIDR
+}
+
+// ACLFindingKind is the kind of an ACL anomaly
+type ACLFindingKind int
+
+const (
+	// ACLShadowed the rule never matches, as earlier rules of which at least one has a different action
+	// match all its packets
+	ACLShadowed = ACLFindingKind(0)
+	// ACLRedundant removing the rule does not change the decision of any packet
+	ACLRedundant = ACLFindingKind(1)
+	// ACLCorrelated the rule partially overlaps an earlier rule of a different action,
+	// the decision of the overlapping packets depends on the order of the rules
+	ACLCorrelated = ACLFindingKind(2)
+)
+
+// String returns the name of the kind
+func (k ACLFindingKind) String() string {
+	switch k {
+	case ACLShadowed:
+		return "shadowed"
+	case ACLRedundant:
+		return "redundant"
+	case ACLCorrelated:
+		return "correlated"
+	}
+	return fmt.Sprintf("ACLFindingKind(%d)", int(k))
+}
+
+// ACLFinding is an anomaly of a rule, Rule and Related are indexes in the rule list
+type ACLFinding struct {
+	Rule int
+	Kind ACLFindingKind
+	// Related are the rules causing the anomaly:
+	// 	the earlier rules matching packets of a shadowed rule;
+	// 	the rules deciding the packets of a redundant rule in its place, none if the default deny does;
+	// 	the earlier rules overlapping a correlated rule.
+	Related []int
+}
+
+// String returns a description like "rule 3 is shadowed by rules 0, 1"
+func (f ACLFinding) String() string {
+	related := make([]string, 0, len(f.Related))
+	for _, i := range f.Related {
+		related = append(related, fmt.Sprint(i))
+	}
+	switch {
+	case f.Kind == ACLCorrelated:
+		return fmt.Sprintf("rule %d is correlated with rules %s", f.Rule, strings.Join(related, ", "))
+	case len(related) == 0:
+		return fmt.Sprintf("rule %d is %v by the default deny", f.Rule, f.Kind)
+	}
+	return fmt.Sprintf("rule %d is %v by rules %s", f.Rule, f.Kind, strings.Join(related, ", "))
+}
+
+// ACLRegion is the set of packets from any of Src to any of Dst
+type ACLRegion struct {
+	Src []*CIDR
+	Dst []*CIDR
+}
+
+// ACLReport is the analysis of an ordered ACL, see AnalyzeACL
+type ACLReport struct {
+	// Findings are ordered by rule
+	Findings []ACLFinding
+	// Permitted is the address space permitted by the ACL, as disjoint regions
+	Permitted []ACLRegion
+}
+
+// aclRect is the set of packets from src to dst, both merged and of the same family
+type aclRect struct {
+	src, dst []Range
+}
+
+// intersect returns the packets both in r and o
+func (r aclRect) intersect(o aclRect) (aclRect, bool) {
+	src := intersectRanges(r.src, o.src)
+	if len(src) == 0 {
+		return aclRect{}, false
+	}
+	dst := intersectRanges(r.dst, o.dst)
+	if len(dst) == 0 {
+		return aclRect{}, false
+	}
+	return aclRect{src: src, dst: dst}, true
+}
+
+// subtract returns the packets of r not in o, as disjoint rects
+func (r aclRect) subtract(o aclRect) []aclRect {
+	common, ok := r.intersect(o)
+	if !ok {
+		return []aclRect{r}
+	}
+	var result []aclRect
+	if src := subtractRanges(r.src, common.src); len(src) != 0 {
+		result = append(result, aclRect{src: src, dst: r.dst})
+	}
+	if dst := subtractRanges(r.dst, common.dst); len(dst) != 0 {
+		result = append(result, aclRect{src: common.src, dst: dst})
+	}
+	return result
+}
+
+// subtractRects returns the packets of rs not in any of os
+func subtractRects(rs, os []aclRect) []aclRect {
+	for _, o := range os {
+		var next []aclRect
+		for _, r := range rs {
+			next = append(next, r.subtract(o)...)
+		}
+		rs = next
+	}
+	return rs
+}
+
+// intersectRects reports whether any packet is both in rs and os
+func intersectRects(rs, os []aclRect) bool {
+	for _, r := range rs {
+		for _, o := range os {
+			if _, ok := r.intersect(o); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// aclUniverse is all the IPs of both families
+var aclUniverse = []Range{
+	{start: net.IP{0, 0, 0, 0}, end: net.IP{255, 255, 255, 255}},
+	{start: make(net.IP, net.IPv6len), end: net.IP(net.CIDRMask(128, 128))},
+}
+
+// aclRuleRects returns the packets matched by a rule, as one rect per family
+func aclRuleRects(rule ACLRule) []aclRect {
+	src, dst := aclUniverse, aclUniverse
+	if len(rule.Src) != 0 {
+		src = cidrRanges(rule.Src)
+	}
+	if len(rule.Dst) != 0 {
+		dst = cidrRanges(rule.Dst)
+	}
+
+	var rects []aclRect
+	for _, family := range aclUniverse {
+		family := []Range{family}
+		r, ok := aclRect{src: intersectRanges(src, family), dst: family}.intersect(aclRect{src: family, dst: dst})
+		if ok {
+			rects = append(rects, r)
+		}
+	}
+	return rects
+}
+
+// AnalyzeACL detects the shadowed, redundant and correlated rules of an ordered ACL,
+// where the first matching rule decides and packets matching no rule are denied,
+// and computes the address space it permits.
+// 	The source and destination of a packet are of the same family, so a rule of an IPv4 Src
+// and an IPv6 Dst matches no packet.
+func AnalyzeACL(rules []ACLRule) *ACLReport {
+	report := &ACLReport{}
+	matched := make([][]aclRect, len(rules))
+	effective := make([][]aclRect, len(rules))
+	for i, rule := range rules {
+		matched[i] = aclRuleRects(rule)
+		effective[i] = matched[i]
+		for j := 0; j < i; j++ {
+			effective[i] = subtractRects(effective[i], matched[j])
+		}
+	}
+
+	for i, rule := range rules {
+		if len(effective[i]) == 0 {
+			// fully matched by earlier rules
+			kind := ACLRedundant
+			var related []int
+			for j := 0; j < i; j++ {
+				if intersectRects(matched[i], effective[j]) {
+					related = append(related, j)
+					if rules[j].Action != rule.Action {
+						kind = ACLShadowed
+					}
+				}
+			}
+			report.Findings = append(report.Findings, ACLFinding{Rule: i, Kind: kind, Related: related})
+			continue
+		}
+
+		var correlated []int
+		for j := 0; j < i; j++ {
+			if rules[j].Action == rule.Action || !intersectRects(matched[i], effective[j]) {
+				continue
+			}
+			// a partial overlap, the rule is neither a subset nor a superset of the earlier one
+			if len(subtractRects(matched[j], matched[i])) != 0 {
+				correlated = append(correlated, j)
+			}
+		}
+		if len(correlated) != 0 {
+			report.Findings = append(report.Findings, ACLFinding{Rule: i, Kind: ACLCorrelated, Related: correlated})
+		}
+
+		// without the rule, its packets would be decided by later rules or the default deny
+		redundant, rest := true, effective[i]
+		var related []int
+		for j := i + 1; j < len(rules) && len(rest) != 0 && redundant; j++ {
+			if intersectRects(rest, matched[j]) {
+				redundant = rules[j].Action == rule.Action
+				related = append(related, j)
+				rest = subtractRects(rest, matched[j])
+			}
+		}
+		if redundant && (len(rest) == 0 || rule.Action == ACLDeny) {
+			report.Findings = append(report.Findings, ACLFinding{Rule: i, Kind: ACLRedundant, Related: related})
+		}
+	}
+
+	var permitted []aclRect
+	for i, rule := range rules {
+		if rule.Action == ACLPermit {
+			permitted = append(permitted, effective[i]...)
+		}
+	}
+	report.Permitted = aclRegions(permitted)
+	return report
+}
+
+// aclRegions returns the regions of disjoint rects, merging rects of the same destination
+func aclRegions(rects []aclRect) []ACLRegion {
+	var merged []aclRect
+	index := map[string]int{}
+	for _, r := range rects {
+		key := fmt.Sprint(r.dst)
+		if i, ok := index[key]; ok {
+			src := append(append([]Range(nil), merged[i].src...), r.src...)
+			merged[i].src = mergeRanges(src)
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, aclRect{src: r.src, dst: r.dst})
+	}
+
+	regions := make([]ACLRegion, 0, len(merged))
+	for _, r := range merged {
+		regions = append(regions, ACLRegion{Src: rangesToCIDRs(r.src), Dst: rangesToCIDRs(r.dst)})
+	}
+	return regions
+}
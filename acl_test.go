@@ -0,0 +1,80 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func aclCIDRs(arr ...string) []*CIDR {
+	cs := make([]*CIDR, 0, len(arr))
+	for _, s := range arr {
+		cs = append(cs, ParseNoError(s))
+	}
+	return cs
+}
+
+func TestAnalyzeACL(t *testing.T) {
+	rules := []ACLRule{
+		// 0: web servers reachable from the office
+		{Action: ACLPermit, Src: aclCIDRs("10.1.0.0/16"), Dst: aclCIDRs("192.168.1.0/24")},
+		// 1: a guest segment blocked, correlated with rule 0
+		{Action: ACLDeny, Src: aclCIDRs("10.1.128.0/17", "10.2.0.0/16"), Dst: aclCIDRs("192.168.0.0/16")},
+		// 2: shadowed by rule 1
+		{Action: ACLPermit, Src: aclCIDRs("10.2.3.0/24"), Dst: aclCIDRs("192.168.1.10/32")},
+		// 3: redundant with rule 0
+		{Action: ACLPermit, Src: aclCIDRs("10.1.0.0/24", "10.1.1.0/24"), Dst: aclCIDRs("192.168.1.0/25")},
+		// 4: redundant with the default deny
+		{Action: ACLDeny, Src: aclCIDRs("10.3.0.0/16")},
+		// 5: management access over IPv6
+		{Action: ACLPermit, Src: aclCIDRs("2001:db8::/48"), Dst: aclCIDRs("2001:db8:1::/48", "192.168.2.0/24")},
+		// 6: redundant with rule 7
+		{Action: ACLPermit, Src: aclCIDRs("10.4.0.0/24"), Dst: aclCIDRs("192.168.4.0/24")},
+		// 7
+		{Action: ACLPermit, Src: aclCIDRs("10.4.0.0/16"), Dst: aclCIDRs("192.168.4.0/24")},
+	}
+	report := AnalyzeACL(rules)
+
+	var findings []string
+	for _, f := range report.Findings {
+		findings = append(findings, f.String())
+	}
+	assert.Equal(t, []string{
+		"rule 1 is correlated with rules 0",
+		"rule 2 is shadowed by rules 1",
+		"rule 3 is redundant by rules 0",
+		"rule 4 is redundant by the default deny",
+		"rule 6 is redundant by rules 7",
+	}, findings)
+
+	var permitted [][2][]string
+	for _, r := range report.Permitted {
+		permitted = append(permitted, [2][]string{cidrStrings(r.Src), cidrStrings(r.Dst)})
+	}
+	assert.Equal(t, [][2][]string{
+		{{"10.1.0.0/16"}, {"192.168.1.0/24"}},
+		{{"2001:db8::/48"}, {"2001:db8:1::/48"}},
+		{{"10.4.0.0/16"}, {"192.168.4.0/24"}},
+	}, permitted)
+}
+
+func TestAnalyzeACL_Any(t *testing.T) {
+	rules := []ACLRule{
+		{Action: ACLDeny, Src: aclCIDRs("10.0.0.0/8")},
+		{Action: ACLPermit, Dst: aclCIDRs("192.168.0.0/16")},
+		{Action: ACLPermit, Src: aclCIDRs("10.0.0.0/24"), Dst: aclCIDRs("192.168.0.1/32")},
+		{Action: ACLDeny},
+	}
+	report := AnalyzeACL(rules)
+	assert.Equal(t, 3, len(report.Findings))
+	assert.Equal(t, ACLFinding{Rule: 1, Kind: ACLCorrelated, Related: []int{0}}, report.Findings[0])
+	assert.Equal(t, ACLFinding{Rule: 2, Kind: ACLShadowed, Related: []int{0}}, report.Findings[1])
+	assert.Equal(t, ACLFinding{Rule: 3, Kind: ACLRedundant}, report.Findings[2])
+
+	assert.Equal(t, 1, len(report.Permitted))
+	assert.Equal(t, []string{"0.0.0.0/5", "8.0.0.0/7", "11.0.0.0/8", "12.0.0.0/6", "16.0.0.0/4", "32.0.0.0/3",
+		"64.0.0.0/2", "128.0.0.0/1"}, cidrStrings(report.Permitted[0].Src))
+	assert.Equal(t, []string{"192.168.0.0/16"}, cidrStrings(report.Permitted[0].Dst))
+
+	assert.Equal(t, "permit", ACLPermit.String())
+	assert.Equal(t, "correlated", ACLCorrelated.String())
+}
@@ -0,0 +1,178 @@
+package cidr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ParseACLFile parses a list of one entry per line, a CIDR like "10.0.0.0/8", a range like "10.0.0.1-10.0.0.9"
+// or a single IP, into an IPSet.
+// 	Blank lines are skipped, and comments start with "#", either on their own line or after an entry.
+func ParseACLFile(r io.Reader) (*IPSet, error) {
+	var rs []Range
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if pos := strings.IndexByte(line, '#'); pos != -1 {
+			line = line[:pos]
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		r, err := ParseRange(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		rs = append(rs, *r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newIPSet(rs), nil
+}
+
+// ACLFile is an allowlist file in the format of ParseACLFile, loaded as immutable IPSet snapshots.
+// 	A reload swaps the snapshot atomically, so concurrent readers see either the previous or the new list,
+// never a partially loaded one. If the file can not be read or parsed, the previous snapshot is kept.
+type ACLFile struct {
+	path     string
+	snapshot atomic.Value
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	err     error
+	stop    chan struct{}
+	done    chan struct{}
+
+	// OnReload is called after each reload triggered by Watch, with the error of the reload.
+	// 	It must be set before Watch is called.
+	OnReload func(err error)
+}
+
+// LoadACLFile loads the file at path, which must be valid
+func LoadACLFile(path string) (*ACLFile, error) {
+	f := &ACLFile{path: path}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Snapshot returns the current IPSet of the file
+func (f *ACLFile) Snapshot() *IPSet {
+	return f.snapshot.Load().(*IPSet)
+}
+
+// Contains reports whether ip is in the current snapshot
+func (f *ACLFile) Contains(ip string) bool {
+	return f.Snapshot().Contains(ip)
+}
+
+// Err returns the error of the last reload, nil if it succeeded
+func (f *ACLFile) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// Reload reads and parses the file, and swaps the snapshot if succeeded
+func (f *ACLFile) Reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = f.load()
+	return f.err
+}
+
+// load reads and parses the file, f.mu must be held
+func (f *ACLFile) load() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	// the version of the file is seen even if invalid, so that it is not reloaded until changed again
+	f.modTime, f.size = info.ModTime(), info.Size()
+
+	set, err := ParseACLFile(file)
+	if err != nil {
+		return fmt.Errorf("%v: %v", f.path, err)
+	}
+	f.snapshot.Store(set)
+	return nil
+}
+
+// changed reports whether the file has been changed since the last reload
+func (f *ACLFile) changed() bool {
+	info, err := os.Stat(f.path)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		// a missing file is reported once
+		return f.err == nil
+	}
+	return !info.ModTime().Equal(f.modTime) || info.Size() != f.size
+}
+
+// Watch polls the modification time and size of the file every interval, and reloads it when changed,
+// until Close is called. Calling Watch while watching has no effect.
+// 	An error is returned if interval is not positive.
+func (f *ACLFile) Watch(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval: %v", interval)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stop != nil {
+		return nil
+	}
+	f.stop, f.done = make(chan struct{}), make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if f.changed() {
+					err := f.Reload()
+					if f.OnReload != nil {
+						f.OnReload(err)
+					}
+				}
+			}
+		}
+	}(f.stop, f.done)
+	return nil
+}
+
+// Close stops watching the file, the current snapshot is still available
+func (f *ACLFile) Close() error {
+	f.mu.Lock()
+	stop, done := f.stop, f.done
+	f.stop, f.done = nil, nil
+	f.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
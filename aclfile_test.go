@@ -0,0 +1,118 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseACLFile(t *testing.T) {
+	set, err := ParseACLFile(strings.NewReader(`# admin networks
+10.0.0.0/24
+10.0.1.0/24   # office
+
+192.168.1.10-192.168.1.20
+2001:db8::1
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/23", "192.168.1.10/31", "192.168.1.12/30", "192.168.1.16/30",
+		"192.168.1.20/32", "2001:db8::1/128"}, cidrStrings(set.CIDRs()))
+
+	_, err = ParseACLFile(strings.NewReader("10.0.0.0/24\n\n10.0.0.300\n"))
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "line 3: "), err.Error())
+	}
+
+	set, err = ParseACLFile(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.True(t, set.IsEmpty())
+}
+
+func writeACLFile(t *testing.T, path, content string, modTime time.Time) {
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestACLFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aclfile")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "allow.txt")
+	now := time.Now()
+
+	_, err = LoadACLFile(path)
+	assert.Error(t, err)
+
+	writeACLFile(t, path, "10.0.0.0/24\n", now)
+	f, err := LoadACLFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	assert.True(t, f.Contains("10.0.0.1"))
+	assert.False(t, f.Contains("10.0.1.1"))
+
+	// an invalid file keeps the previous snapshot
+	writeACLFile(t, path, "10.0.1.0/24\ninvalid\n", now.Add(time.Second))
+	err = f.Reload()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "line 2: ")
+	}
+	assert.Equal(t, err, f.Err())
+	assert.True(t, f.Contains("10.0.0.1"))
+
+	reloaded := make(chan error, 10)
+	f.OnReload = func(err error) {
+		reloaded <- err
+	}
+	assert.Error(t, f.Watch(0))
+	assert.Error(t, f.Watch(-time.Second))
+	assert.NoError(t, f.Watch(10*time.Millisecond))
+	assert.NoError(t, f.Watch(10*time.Millisecond))
+
+	stop := make(chan struct{})
+	go func() {
+		// concurrent readers
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				f.Snapshot().Contains("10.0.1.1")
+			}
+		}
+	}()
+	defer close(stop)
+
+	writeACLFile(t, path, "10.0.1.0/24\n", now.Add(2*time.Second))
+	select {
+	case err := <-reloaded:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("not reloaded")
+	}
+	assert.NoError(t, f.Err())
+	assert.False(t, f.Contains("10.0.0.1"))
+	assert.True(t, f.Contains("10.0.1.1"))
+
+	// a removed file is reported once
+	assert.NoError(t, os.Remove(path))
+	select {
+	case err := <-reloaded:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("not reloaded")
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, len(reloaded))
+	assert.True(t, f.Contains("10.0.1.1"))
+
+	assert.NoError(t, f.Close())
+	assert.NoError(t, f.Close())
+}
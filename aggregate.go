@@ -0,0 +1,138 @@
+package cidr
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// cidrRanges returns the merged ranges of cs
+func cidrRanges(cs []*CIDR) []Range {
+	rs := make([]Range, 0, len(cs))
+	for _, c := range cs {
+		rs = append(rs, cidrRange(c))
+	}
+	return mergeRanges(rs)
+}
+
+// Aggregate returns the minimal list of CIDRs covering exactly the same IPs as cs, in ascending order.
+// 	Duplicated and overlapping CIDRs are removed, adjacent CIDRs are merged whenever possible.
+// For example, "192.168.0.0/24", "192.168.1.0/24" and "192.168.1.128/25" result in "192.168.0.0/23".
+func Aggregate(cs []*CIDR) []*CIDR {
+	return rangesToCIDRs(cidrRanges(cs))
+}
+
+// budgetNode is a node of the compressed binary trie of disjoint CIDRs, used by AggregateWithBudget
+type budgetNode struct {
+	cidr     *CIDR
+	children [2]*budgetNode
+	// owned is the number of IPs of the subtree leaves
+	owned *big.Int
+	// extra[k-1] is the minimal number of extra IPs when the subtree is covered by at most k CIDRs
+	extra []*big.Int
+	// split[k-1] is the number of CIDRs given to the left child, 0 if the subtree is covered by cidr itself
+	split []int
+}
+
+// newBudgetNode builds the compressed trie of cs, which must be sorted, disjoint and of the same family,
+// and solves the budget of every subtree up to n CIDRs
+func newBudgetNode(cs []*CIDR, n int) *budgetNode {
+	if len(cs) == 1 {
+		return &budgetNode{cidr: cs[0], owned: cs[0].IPCount(), extra: []*big.Int{big.NewInt(0)}, split: []int{0}}
+	}
+
+	// the common prefix of the first and the last is common to all, as cs are sorted
+	first, _ := trieKey(cs[0])
+	last, _ := trieKey(cs[len(cs)-1])
+	ones := commonPrefixLen(first, last)
+	idx := sort.Search(len(cs), func(i int) bool {
+		ip, _ := trieKey(cs[i])
+		return ipBit(ip, ones) == 1
+	})
+	node := &budgetNode{cidr: newCIDR(first, ones)}
+	node.children[0] = newBudgetNode(cs[:idx], n)
+	node.children[1] = newBudgetNode(cs[idx:], n)
+
+	// a single CIDR covering the subtree is the node itself
+	left, right := node.children[0], node.children[1]
+	node.owned = big.NewInt(0).Add(left.owned, right.owned)
+	node.extra = []*big.Int{big.NewInt(0).Sub(node.cidr.IPCount(), node.owned)}
+	node.split = []int{0}
+	for k := 2; k <= n && k <= len(left.extra)+len(right.extra); k++ {
+		var best *big.Int
+		bestSplit := 0
+		for k1 := 1; k1 < k && k1 <= len(left.extra); k1++ {
+			k2 := k - k1
+			if k2 > len(right.extra) {
+				continue
+			}
+			sum := big.NewInt(0).Add(left.extra[k1-1], right.extra[k2-1])
+			if best == nil || sum.Cmp(best) < 0 {
+				best, bestSplit = sum, k1
+			}
+		}
+		node.extra = append(node.extra, best)
+		node.split = append(node.split, bestSplit)
+	}
+	return node
+}
+
+// collect returns the CIDRs covering the subtree with k CIDRs
+func (n *budgetNode) collect(k int) []*CIDR {
+	k1 := n.split[k-1]
+	if k1 == 0 {
+		return []*CIDR{n.cidr}
+	}
+	return append(n.children[0].collect(k1), n.children[1].collect(k-k1)...)
+}
+
+// AggregateWithBudget returns at most n CIDRs covering all IPs of cs, including as few extra IPs as possible,
+// for devices accepting a limited number of prefixes in a filter.
+// 	The extra IPs pulled in are returned as well, both lists are in ascending order.
+// If the exact aggregation of cs (see Aggregate) fits in n, no extra IP is included.
+// IPv4 and IPv6 need at least one CIDR each.
+func AggregateWithBudget(cs []*CIDR, n int) (result []*CIDR, extra []*CIDR, err error) {
+	owned := cidrRanges(cs)
+	exact := rangesToCIDRs(owned)
+	if len(exact) <= n {
+		return exact, nil, nil
+	}
+
+	var trees []*budgetNode
+	for i := 0; i < len(exact); {
+		j := i + 1
+		for j < len(exact) && exact[j].IsIPv4() == exact[i].IsIPv4() {
+			j++
+		}
+		trees = append(trees, newBudgetNode(exact[i:j], n))
+		i = j
+	}
+	if n < len(trees) {
+		return nil, nil, fmt.Errorf("budget %d is less than the number of families %d", n, len(trees))
+	}
+
+	if len(trees) == 1 {
+		result = trees[0].collect(len(trees[0].extra))
+	} else {
+		// share the budget between IPv4 and IPv6
+		v4, v6 := trees[0], trees[1]
+		var best *big.Int
+		bestK4 := 0
+		for k4 := 1; k4 <= len(v4.extra) && k4 < n; k4++ {
+			k6 := n - k4
+			if k6 > len(v6.extra) {
+				k6 = len(v6.extra)
+			}
+			sum := big.NewInt(0).Add(v4.extra[k4-1], v6.extra[k6-1])
+			if best == nil || sum.Cmp(best) < 0 {
+				best, bestK4 = sum, k4
+			}
+		}
+		k6 := n - bestK4
+		if k6 > len(v6.extra) {
+			k6 = len(v6.extra)
+		}
+		result = append(v4.collect(bestK4), v6.collect(k6)...)
+	}
+	return result, rangesToCIDRs(subtractRanges(cidrRanges(result), owned)), nil
+}
@@ -0,0 +1,77 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	cs := []*CIDR{
+		ParseNoError("192.168.1.128/25"),
+		ParseNoError("192.168.1.0/24"),
+		ParseNoError("192.168.0.0/24"),
+		ParseNoError("2001:db8:0:1::/64"),
+		ParseNoError("2001:db8::/64"),
+		ParseNoError("10.0.0.0/24"),
+		ParseNoError("10.0.2.0/24"),
+	}
+	assert.Equal(t, []string{"10.0.0.0/24", "10.0.2.0/24", "192.168.0.0/23", "2001:db8::/63"}, cidrStrings(Aggregate(cs)))
+	assert.Equal(t, []string{}, cidrStrings(Aggregate(nil)))
+}
+
+func TestAggregateWithBudget(t *testing.T) {
+	cs := []*CIDR{
+		ParseNoError("10.0.0.0/24"),
+		ParseNoError("10.0.2.0/24"),
+		ParseNoError("10.0.3.0/24"),
+		ParseNoError("10.1.0.0/24"),
+		ParseNoError("10.0.0.0/24"),
+	}
+
+	// fits in the budget
+	result, extra, err := AggregateWithBudget(cs, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/24", "10.0.2.0/23", "10.1.0.0/24"}, cidrStrings(result))
+	assert.Equal(t, 0, len(extra))
+
+	result, extra, err = AggregateWithBudget(cs, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/22", "10.1.0.0/24"}, cidrStrings(result))
+	assert.Equal(t, []string{"10.0.1.0/24"}, cidrStrings(extra))
+
+	result, extra, err = AggregateWithBudget(cs, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/15"}, cidrStrings(result))
+	assert.Equal(t, []string{"10.0.1.0/24", "10.0.4.0/22", "10.0.8.0/21", "10.0.16.0/20", "10.0.32.0/19",
+		"10.0.64.0/18", "10.0.128.0/17", "10.1.1.0/24", "10.1.2.0/23", "10.1.4.0/22", "10.1.8.0/21",
+		"10.1.16.0/20", "10.1.32.0/19", "10.1.64.0/18", "10.1.128.0/17"}, cidrStrings(extra))
+
+	// the cheapest merge is chosen, not the leftmost
+	cs = []*CIDR{
+		ParseNoError("192.168.0.0/24"),
+		ParseNoError("192.168.2.0/24"),
+		ParseNoError("192.168.4.0/24"),
+		ParseNoError("192.168.4.128/25"),
+		ParseNoError("192.168.5.0/25"),
+	}
+	result, extra, err = AggregateWithBudget(cs, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.0.0/22", "192.168.4.0/23"}, cidrStrings(result))
+	assert.Equal(t, []string{"192.168.1.0/24", "192.168.3.0/24", "192.168.5.128/25"}, cidrStrings(extra))
+
+	// the budget is shared between families, merging IPv6 /64s would cost far more
+	cs = append(cs, ParseNoError("2001:db8::/64"), ParseNoError("2001:db8:0:2::/64"))
+	result, extra, err = AggregateWithBudget(cs, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.0.0/21", "2001:db8::/64", "2001:db8:0:2::/64"}, cidrStrings(result))
+	assert.Equal(t, []string{"192.168.1.0/24", "192.168.3.0/24", "192.168.5.128/25", "192.168.6.0/23"}, cidrStrings(extra))
+	result, extra, err = AggregateWithBudget(cs, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.0.0/21", "2001:db8::/62"}, cidrStrings(result))
+	assert.Equal(t, 6, len(extra))
+
+	_, _, err = AggregateWithBudget(cs, 1)
+	assert.Error(t, err)
+	_, _, err = AggregateWithBudget(cs, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package cidr
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"net"
+)
+
+// TruncateIP returns a copy of ip with the last ipv4Bits bits zeroed if IPv4, or the last ipv6Bits bits if IPv6,
+// like TruncateIP(ip, 8, 80) for the common anonymization of logs to the /24 and /48.
+// 	Returns nil if ip is invalid.
+func TruncateIP(ip net.IP, ipv4Bits, ipv6Bits int) net.IP {
+	ip = normalizeIP(ip)
+	if ip == nil {
+		return nil
+	}
+	bits := ipv6Bits
+	if len(ip) == net.IPv4len {
+		bits = ipv4Bits
+	}
+	ones := len(ip)*8 - bits
+	if ones < 0 {
+		ones = 0
+	} else if ones > len(ip)*8 {
+		ones = len(ip) * 8
+	}
+	return ip.Mask(net.CIDRMask(ones, len(ip)*8))
+}
+
+// AnonymizerKeySize is the size of the key of an Anonymizer
+const AnonymizerKeySize = 32
+
+// Anonymizer anonymizes IPs with Crypto-PAn, a keyed prefix-preserving anonymization:
+// two IPs sharing a k-bit prefix share a k-bit prefix after anonymization, and no more,
+// so the anonymized IPs keep the structure of the networks.
+// 	The output is deterministic for the same key, IPv4 output is compatible with the reference implementation
+// of Crypto-PAn, IPv6 is anonymized the same way over 128 bits.
+// Anonymizer is safe for concurrent use.
+type Anonymizer struct {
+	block cipher.Block
+	pad   []byte
+}
+
+// NewAnonymizer returns an Anonymizer of a 32 bytes secret key, the first 16 bytes are the AES key,
+// and the last 16 bytes are encrypted as the pad
+func NewAnonymizer(key []byte) (*Anonymizer, error) {
+	if len(key) != AnonymizerKeySize {
+		return nil, fmt.Errorf("invalid key size %d, must be %d", len(key), AnonymizerKeySize)
+	}
+	block, err := aes.NewCipher(key[:16])
+	if err != nil {
+		return nil, err
+	}
+	a := &Anonymizer{block: block, pad: make([]byte, aes.BlockSize)}
+	block.Encrypt(a.pad, key[16:])
+	return a, nil
+}
+
+// Anonymize returns the anonymized IP of ip, nil if ip is invalid.
+// 	IPv4-mapped IPv6 addresses are anonymized as IPv4.
+func (a *Anonymizer) Anonymize(ip net.IP) net.IP {
+	ip = normalizeIP(ip)
+	if ip == nil {
+		return nil
+	}
+
+	// bit i of the one-time pad is the first bit of the encryption of the first i bits of ip padded with the pad
+	input, output := make([]byte, aes.BlockSize), make([]byte, aes.BlockSize)
+	result := make(net.IP, len(ip))
+	for i := 0; i < len(ip)*8; i++ {
+		copy(input, a.pad)
+		copy(input, ip[:i/8])
+		if n := i % 8; n != 0 {
+			mask := byte(0xff) << uint(8-n)
+			input[i/8] = ip[i/8]&mask | a.pad[i/8]&^mask
+		}
+		a.block.Encrypt(output, input)
+		result[i/8] |= output[0] >> 7 << uint(7-i%8)
+	}
+	for i := range result {
+		result[i] ^= ip[i]
+	}
+	return result
+}
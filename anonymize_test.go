@@ -0,0 +1,60 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestTruncateIP(t *testing.T) {
+	assert.Equal(t, "192.0.2.0", TruncateIP(net.ParseIP("192.0.2.123"), 8, 80).String())
+	assert.Equal(t, "192.0.2.0", TruncateIP(net.ParseIP("::ffff:192.0.2.123"), 8, 80).String())
+	assert.Equal(t, "2001:db8:1::", TruncateIP(net.ParseIP("2001:db8:1:2:3:4:5:6"), 8, 80).String())
+	assert.Equal(t, "192.0.2.120", TruncateIP(net.ParseIP("192.0.2.123"), 3, 0).String())
+	assert.Equal(t, "192.0.2.123", TruncateIP(net.ParseIP("192.0.2.123"), 0, 0).String())
+	assert.Equal(t, "192.0.2.123", TruncateIP(net.ParseIP("192.0.2.123"), -1, 0).String())
+	assert.Equal(t, "0.0.0.0", TruncateIP(net.ParseIP("192.0.2.123"), 40, 0).String())
+	assert.Nil(t, TruncateIP(nil, 8, 80))
+}
+
+func TestAnonymizer(t *testing.T) {
+	// the sample key and data of the reference implementation of Crypto-PAn
+	key := []byte{21, 34, 23, 141, 51, 164, 207, 128, 19, 10, 91, 22, 73, 144, 125, 16,
+		216, 152, 143, 131, 121, 121, 101, 39, 98, 87, 76, 45, 42, 132, 34, 2}
+	a, err := NewAnonymizer(key)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for ip, anonymized := range map[string]string{
+		"128.11.68.132":   "135.242.180.132",
+		"129.118.74.4":    "134.136.186.123",
+		"130.132.252.244": "133.68.164.234",
+		"141.223.7.43":    "141.167.8.160",
+		"141.233.145.108": "141.129.237.235",
+		"156.29.3.236":    "147.225.12.42",
+		"165.247.96.84":   "162.9.99.234",
+		"166.107.77.190":  "160.132.178.185",
+		"192.102.249.13":  "252.138.62.131",
+		"192.215.32.125":  "252.43.47.189",
+	} {
+		assert.Equal(t, anonymized, a.Anonymize(net.ParseIP(ip)).String(), ip)
+	}
+	assert.Equal(t, "135.242.180.132", a.Anonymize(net.ParseIP("::ffff:128.11.68.132")).String())
+	assert.Nil(t, a.Anonymize(nil))
+
+	// IPv6 prefixes are preserved
+	x := a.Anonymize(net.ParseIP("2001:db8:1:2::1"))
+	y := a.Anonymize(net.ParseIP("2001:db8:1:3::1"))
+	assert.Equal(t, 16, len(x))
+	assert.Equal(t, 63, commonPrefixLen(x, y))
+	assert.NotEqual(t, "2001:db8:1:2::1", x.String())
+	assert.Equal(t, x, a.Anonymize(net.ParseIP("2001:db8:1:2::1")))
+
+	// another key
+	key[0]++
+	b, _ := NewAnonymizer(key)
+	assert.NotEqual(t, x, b.Anonymize(net.ParseIP("2001:db8:1:2::1")))
+
+	_, err = NewAnonymizer(key[:16])
+	assert.Error(t, err)
+}
@@ -32,6 +32,13 @@ func Parse(s string) (*CIDR, error) {
 	return &CIDR{ip: i, ipNet: n, original: s}, nil
 }
 
+// newCIDR returns the CIDR of ip masked to ones bits, ip must be normalized (see normalizeIP)
+func newCIDR(ip net.IP, ones int) *CIDR {
+	mask := net.CIDRMask(ones, len(ip)*8)
+	ipNet := &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	return &CIDR{ip: ipNet.IP.To16(), ipNet: ipNet, original: ipNet.String()}
+}
+
 // ParseNoError parses s as a CIDR notation IP address and mask length,
 // but ignores any error. Use with caution.
 func ParseNoError(s string) *CIDR {
@@ -0,0 +1,138 @@
+package cidr
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIPResolver resolves the IP of the client of an HTTP request behind trusted proxies, like load balancers.
+// 	If the peer of the connection is a trusted proxy, the addresses of the configured Header are walked
+// from right to left, and the first untrusted one is the client, as a client can forge the addresses on its left.
+// If all of them are trusted, the leftmost is the client.
+// An address which can not be parsed, like "unknown" or an obfuscated identifier, stops the walk,
+// and the last trusted address is the client.
+// Only the header set by the trusted proxies is read, as a client can send any of the others.
+type ClientIPResolver struct {
+	// Header is the header the trusted proxies set, X-Forwarded-For by default,
+	// Forwarded (RFC 7239) and X-Real-IP are supported as well
+	Header string
+
+	trusted *Trie
+}
+
+// NewClientIPResolver returns a ClientIPResolver of the trusted proxies, reading X-Forwarded-For
+func NewClientIPResolver(trusted []*CIDR) *ClientIPResolver {
+	r := &ClientIPResolver{Header: "X-Forwarded-For", trusted: NewTrie()}
+	for _, c := range trusted {
+		r.trusted.Insert(c, nil)
+	}
+	return r
+}
+
+// parseHostIP parses an address with an optional port, like "192.0.2.1", "192.0.2.1:8080", "2001:db8::1"
+// or "[2001:db8::1]:8080", quoted or not, nil if invalid
+func parseHostIP(s string) net.IP {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if strings.HasPrefix(s, "[") {
+		if pos := strings.IndexByte(s, ']'); pos != -1 {
+			s = s[1:pos]
+		}
+	} else if strings.Count(s, ":") == 1 {
+		s = s[:strings.IndexByte(s, ':')]
+	}
+	return parseIP(s)
+}
+
+// forwardedFor returns the "for" parameters of Forwarded headers, "" for elements without it
+func forwardedFor(headers []string) []string {
+	var hops []string
+	for _, header := range headers {
+		for _, element := range strings.Split(header, ",") {
+			var hop string
+			for _, pair := range strings.Split(element, ";") {
+				pair = strings.TrimSpace(pair)
+				if len(pair) > 4 && strings.EqualFold(pair[:4], "for=") {
+					hop = pair[4:]
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// hops returns the addresses of the proxy chain of req in the configured header, from the client to the last proxy
+func (r *ClientIPResolver) hops(req *http.Request) []string {
+	name := http.CanonicalHeaderKey(r.Header)
+	if name == "" {
+		name = "X-Forwarded-For"
+	}
+	headers := req.Header[name]
+	if name == "Forwarded" {
+		return forwardedFor(headers)
+	}
+	var hops []string
+	for _, header := range headers {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	return hops
+}
+
+// Resolve returns the IP of the client of req, nil if the RemoteAddr of req is invalid
+func (r *ClientIPResolver) Resolve(req *http.Request) net.IP {
+	client := parseHostIP(req.RemoteAddr)
+	if client == nil || !r.trusted.contains(client) {
+		return client
+	}
+	hops := r.hops(req)
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHostIP(hops[i])
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !r.trusted.contains(ip) {
+			break
+		}
+	}
+	return client
+}
+
+type clientIPKey struct{}
+
+// ClientIPFromContext returns the client IP stored by the ClientIPResolver middleware
+func ClientIPFromContext(ctx context.Context) (net.IP, bool) {
+	ip, ok := ctx.Value(clientIPKey{}).(net.IP)
+	return ip, ok
+}
+
+// Middleware returns an http.Handler middleware storing the client IP in the request context,
+// see ClientIPFromContext.
+// 	Requests of a client in deny, or not in allow if not empty, as well as those whose client IP can not be resolved
+// when allow or deny is set, are rejected with 403 Forbidden.
+func (r *ClientIPResolver) Middleware(allow, deny []*CIDR) func(next http.Handler) http.Handler {
+	allowTrie, denyTrie := NewTrie(), NewTrie()
+	for _, c := range allow {
+		allowTrie.Insert(c, nil)
+	}
+	for _, c := range deny {
+		denyTrie.Insert(c, nil)
+	}
+	enforce := len(allow) != 0 || len(deny) != 0
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ip := r.Resolve(req)
+			if enforce && (ip == nil || denyTrie.contains(ip) || (len(allow) != 0 && !allowTrie.contains(ip))) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			if ip != nil {
+				req = req.WithContext(context.WithValue(req.Context(), clientIPKey{}, ip))
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
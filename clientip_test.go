@@ -0,0 +1,85 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver_Resolve(t *testing.T) {
+	r := NewClientIPResolver([]*CIDR{ParseNoError("10.0.0.0/8"), ParseNoError("2001:db8:ffff::/48")})
+	resolve := func(remote string, header ...string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Add(header[i], header[i+1])
+		}
+		if ip := r.Resolve(req); ip != nil {
+			return ip.String()
+		}
+		return ""
+	}
+
+	// untrusted peers are the client
+	assert.Equal(t, "198.51.100.7", resolve("198.51.100.7:5000", "X-Forwarded-For", "1.2.3.4"))
+	assert.Equal(t, "", resolve("invalid"))
+
+	// the forged leftmost address is ignored
+	assert.Equal(t, "203.0.113.5", resolve("10.0.0.1:5000", "X-Forwarded-For", "1.2.3.4, 203.0.113.5, 10.0.0.2"))
+	assert.Equal(t, "203.0.113.5", resolve("10.0.0.1:5000",
+		"X-Forwarded-For", "1.2.3.4", "X-Forwarded-For", "203.0.113.5:4711,10.0.0.2"))
+	assert.Equal(t, "10.0.0.3", resolve("10.0.0.1:5000", "X-Forwarded-For", "10.0.0.3, 10.0.0.2"))
+	assert.Equal(t, "10.0.0.2", resolve("10.0.0.1:5000", "X-Forwarded-For", "unknown, 10.0.0.2"))
+	assert.Equal(t, "10.0.0.1", resolve("10.0.0.1:5000"))
+
+	// only the configured header is read, a Forwarded header sent by the client is ignored
+	assert.Equal(t, "203.0.113.5", resolve("10.0.0.1:5000",
+		"Forwarded", "for=1.2.3.4", "X-Forwarded-For", "203.0.113.5"))
+	assert.Equal(t, "10.0.0.1", resolve("10.0.0.1:5000", "X-Real-IP", "192.0.2.60"))
+
+	r.Header = "Forwarded"
+	assert.Equal(t, "2001:db8:cafe::17", resolve("[2001:db8:ffff::1]:443",
+		"Forwarded", `for=192.0.2.43, for="[2001:db8:cafe::17]:4711";proto=https`,
+		"Forwarded", `For="[2001:db8:ffff::2]";by=10.0.0.1`,
+		"X-Forwarded-For", "1.2.3.4"))
+	assert.Equal(t, "10.0.0.2", resolve("10.0.0.1:5000", "Forwarded", "for=_hidden, for=10.0.0.2"))
+	assert.Equal(t, "10.0.0.1", resolve("10.0.0.1:5000", "Forwarded", "by=10.0.0.2"))
+	assert.Equal(t, "10.0.0.1", resolve("10.0.0.1:5000", "X-Forwarded-For", "203.0.113.5"))
+
+	r.Header = "x-real-ip"
+	assert.Equal(t, "192.0.2.60", resolve("10.0.0.1:5000", "X-Real-IP", "192.0.2.60"))
+	assert.Equal(t, "192.0.2.60", resolve("10.0.0.1:5000", "X-Real-IP", "::ffff:192.0.2.60"))
+}
+
+func TestClientIPResolver_Middleware(t *testing.T) {
+	r := NewClientIPResolver([]*CIDR{ParseNoError("10.0.0.0/8")})
+	var got string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ip, ok := ClientIPFromContext(req.Context())
+		assert.True(t, ok)
+		got = ip.String()
+	})
+	serve := func(h http.Handler, remote, xff string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", xff)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	h := r.Middleware(nil, nil)(handler)
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:5000", "192.0.2.1"))
+	assert.Equal(t, "192.0.2.1", got)
+
+	h = r.Middleware([]*CIDR{ParseNoError("192.0.2.0/24")}, []*CIDR{ParseNoError("192.0.2.128/25")})(handler)
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:5000", "192.0.2.1"))
+	assert.Equal(t, http.StatusForbidden, serve(h, "10.0.0.1:5000", "192.0.2.200"))
+	assert.Equal(t, http.StatusForbidden, serve(h, "10.0.0.1:5000", "198.51.100.1"))
+	assert.Equal(t, http.StatusForbidden, serve(h, "198.51.100.1:5000", "192.0.2.1"))
+	assert.Equal(t, http.StatusForbidden, serve(h, "invalid", ""))
+
+	_, ok := ClientIPFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	assert.False(t, ok)
+}
@@ -0,0 +1,139 @@
+package cidr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	ProviderAWS   = "aws"
+	ProviderGCP   = "gcp"
+	ProviderAzure = "azure"
+)
+
+// newCloudPrefix returns the TaggedPrefix of prefix with the non-empty tags of kv (key, value, ...)
+func newCloudPrefix(prefix string, kv ...string) (*TaggedPrefix, error) {
+	c, err := Parse(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prefix %v: %v", prefix, err)
+	}
+	tags := make(Tags, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			tags[kv[i]] = kv[i+1]
+		}
+	}
+	return &TaggedPrefix{CIDR: c, Tags: tags}, nil
+}
+
+// ParseAWSIPRanges parses the AWS ip-ranges.json feed, https://ip-ranges.amazonaws.com/ip-ranges.json.
+// 	Prefixes are tagged with provider, region, service and "network_border_group".
+// Note that AWS lists every prefix under service "AMAZON" as well as under the specific service.
+func ParseAWSIPRanges(r io.Reader) ([]*TaggedPrefix, error) {
+	type awsPrefix struct {
+		IPPrefix           string `json:"ip_prefix"`
+		IPv6Prefix         string `json:"ipv6_prefix"`
+		Region             string `json:"region"`
+		Service            string `json:"service"`
+		NetworkBorderGroup string `json:"network_border_group"`
+	}
+	var doc struct {
+		Prefixes     []awsPrefix `json:"prefixes"`
+		IPv6Prefixes []awsPrefix `json:"ipv6_prefixes"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	result := make([]*TaggedPrefix, 0, len(doc.Prefixes)+len(doc.IPv6Prefixes))
+	for _, p := range append(doc.Prefixes, doc.IPv6Prefixes...) {
+		prefix := p.IPPrefix
+		if prefix == "" {
+			prefix = p.IPv6Prefix
+		}
+		tp, err := newCloudPrefix(prefix,
+			TagProvider, ProviderAWS,
+			TagRegion, p.Region,
+			TagService, p.Service,
+			"network_border_group", p.NetworkBorderGroup)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tp)
+	}
+	return result, nil
+}
+
+// ParseGCPCloudRanges parses the Google Cloud cloud.json feed, https://www.gstatic.com/ipranges/cloud.json.
+// 	Prefixes are tagged with provider, region (the "scope" of the feed) and service.
+func ParseGCPCloudRanges(r io.Reader) ([]*TaggedPrefix, error) {
+	var doc struct {
+		Prefixes []struct {
+			IPv4Prefix string `json:"ipv4Prefix"`
+			IPv6Prefix string `json:"ipv6Prefix"`
+			Service    string `json:"service"`
+			Scope      string `json:"scope"`
+		} `json:"prefixes"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	result := make([]*TaggedPrefix, 0, len(doc.Prefixes))
+	for _, p := range doc.Prefixes {
+		prefix := p.IPv4Prefix
+		if prefix == "" {
+			prefix = p.IPv6Prefix
+		}
+		tp, err := newCloudPrefix(prefix,
+			TagProvider, ProviderGCP,
+			TagRegion, p.Scope,
+			TagService, p.Service)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tp)
+	}
+	return result, nil
+}
+
+// ParseAzureServiceTags parses the Azure service tags JSON file (ServiceTags_Public_*.json).
+// 	Prefixes are tagged with provider, region, service and "name" (the service tag, like "AzureCloud.eastus").
+// The service is the system service of the tag, or the tag name without region if absent.
+func ParseAzureServiceTags(r io.Reader) ([]*TaggedPrefix, error) {
+	var doc struct {
+		Values []struct {
+			Name       string `json:"name"`
+			Properties struct {
+				Region          string   `json:"region"`
+				SystemService   string   `json:"systemService"`
+				AddressPrefixes []string `json:"addressPrefixes"`
+			} `json:"properties"`
+		} `json:"values"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var result []*TaggedPrefix
+	for _, v := range doc.Values {
+		service := v.Properties.SystemService
+		if service == "" {
+			service = strings.SplitN(v.Name, ".", 2)[0]
+		}
+		for _, prefix := range v.Properties.AddressPrefixes {
+			tp, err := newCloudPrefix(prefix,
+				TagProvider, ProviderAzure,
+				TagRegion, v.Properties.Region,
+				TagService, service,
+				"name", v.Name)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, tp)
+		}
+	}
+	return result, nil
+}
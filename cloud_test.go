@@ -0,0 +1,79 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseAWSIPRanges(t *testing.T) {
+	ps, err := ParseAWSIPRanges(strings.NewReader(`{
+  "syncToken": "1700000000",
+  "createDate": "2023-11-14-22-13-20",
+  "prefixes": [
+    {"ip_prefix": "3.5.140.0/22", "region": "ap-northeast-2", "service": "AMAZON", "network_border_group": "ap-northeast-2"},
+    {"ip_prefix": "3.5.140.0/22", "region": "ap-northeast-2", "service": "S3", "network_border_group": "ap-northeast-2"}
+  ],
+  "ipv6_prefixes": [
+    {"ipv6_prefix": "2600:1f14::/35", "region": "us-west-2", "service": "EC2", "network_border_group": "us-west-2"}
+  ]
+}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(ps))
+	assert.Equal(t, "2600:1f14::/35", ps[2].CIDR.String())
+	assert.Equal(t, Tags{TagProvider: ProviderAWS, TagRegion: "us-west-2", TagService: "EC2", "network_border_group": "us-west-2"}, ps[2].Tags)
+
+	s := NewTaggedSet()
+	s.AddPrefixes(ps)
+	var services []string
+	for _, p := range s.Lookup("3.5.141.1") {
+		services = append(services, p.Tags[TagService])
+	}
+	assert.Equal(t, []string{"AMAZON", "S3"}, services)
+
+	_, err = ParseAWSIPRanges(strings.NewReader(`{"prefixes": [{"ip_prefix": "3.5.140.0/33"}]}`))
+	assert.Error(t, err)
+	_, err = ParseAWSIPRanges(strings.NewReader(`{`))
+	assert.Error(t, err)
+}
+
+func TestParseGCPCloudRanges(t *testing.T) {
+	ps, err := ParseGCPCloudRanges(strings.NewReader(`{
+  "syncToken": "1700000000",
+  "creationTime": "2023-11-14T22:13:20.000000",
+  "prefixes": [
+    {"ipv4Prefix": "34.80.0.0/15", "service": "Google Cloud", "scope": "asia-east1"},
+    {"ipv6Prefix": "2600:1900:4000::/44", "service": "Google Cloud", "scope": "us-central1"}
+  ]
+}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ps))
+	assert.Equal(t, "34.80.0.0/15", ps[0].CIDR.String())
+	assert.Equal(t, Tags{TagProvider: ProviderGCP, TagRegion: "asia-east1", TagService: "Google Cloud"}, ps[0].Tags)
+	assert.Equal(t, "us-central1", ps[1].Tags[TagRegion])
+}
+
+func TestParseAzureServiceTags(t *testing.T) {
+	ps, err := ParseAzureServiceTags(strings.NewReader(`{
+  "changeNumber": 1,
+  "cloud": "Public",
+  "values": [
+    {
+      "name": "AzureCloud.eastus",
+      "id": "AzureCloud.eastus",
+      "properties": {"changeNumber": 1, "region": "eastus", "regionId": 32, "platform": "Azure", "systemService": "",
+        "addressPrefixes": ["13.68.128.0/17", "2603:1030::/45"]}
+    },
+    {
+      "name": "Storage",
+      "id": "Storage",
+      "properties": {"changeNumber": 1, "region": "", "platform": "Azure", "systemService": "AzureStorage",
+        "addressPrefixes": ["13.65.107.32/28"]}
+    }
+  ]
+}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(ps))
+	assert.Equal(t, Tags{TagProvider: ProviderAzure, TagRegion: "eastus", TagService: "AzureCloud", "name": "AzureCloud.eastus"}, ps[1].Tags)
+	assert.Equal(t, Tags{TagProvider: ProviderAzure, TagService: "AzureStorage", "name": "Storage"}, ps[2].Tags)
+}
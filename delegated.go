@@ -0,0 +1,149 @@
+package cidr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DelegatedRecord is an IP record of a RIR statistics exchange file, like delegated-ripencc-extended-latest,
+// see https://www.apnic.net/about-apnic/corporate-documents/documents/resource-guidelines/rir-statistics-exchange-format/
+type DelegatedRecord struct {
+	Registry string
+	CC       string // ISO 3166 2-letter country code, "ZZ" or empty for unassigned space
+	Type     string // "ipv4" or "ipv6"
+	Start    net.IP
+	Value    int64  // number of IPs for IPv4, prefix length for IPv6
+	Date     string // yyyymmdd, may be empty
+	Status   string // "allocated", "assigned", "available" or "reserved"
+	OpaqueID string // only in extended files
+	// CIDRs covering exactly the IPs of the record, an IPv4 count is not always a power of two
+	CIDRs []*CIDR
+}
+
+// parseDelegatedRecord parses the fields of an ipv4 or ipv6 record
+func parseDelegatedRecord(fields []string) (*DelegatedRecord, error) {
+	if len(fields) < 7 {
+		return nil, fmt.Errorf("too few fields")
+	}
+	rec := &DelegatedRecord{
+		Registry: fields[0],
+		CC:       fields[1],
+		Type:     fields[2],
+		Date:     fields[5],
+		Status:   fields[6],
+	}
+	if len(fields) > 7 {
+		rec.OpaqueID = fields[7]
+	}
+
+	var err error
+	if rec.Start = parseIP(fields[3]); rec.Start == nil || (rec.Type == "ipv4") != (len(rec.Start) == net.IPv4len) {
+		return nil, fmt.Errorf("invalid start: %v", fields[3])
+	}
+	if rec.Value, err = strconv.ParseInt(fields[4], 10, 64); err != nil || rec.Value < 1 {
+		return nil, fmt.Errorf("invalid value: %v", fields[4])
+	}
+
+	if rec.Type == "ipv6" {
+		if rec.Value > 128 {
+			return nil, fmt.Errorf("invalid prefix length: %v", fields[4])
+		}
+		c := newCIDR(rec.Start, int(rec.Value))
+		if !c.ipNet.IP.Equal(rec.Start) {
+			return nil, fmt.Errorf("start is not aligned to prefix length: %v/%v", fields[3], fields[4])
+		}
+		rec.CIDRs = []*CIDR{c}
+		return rec, nil
+	}
+
+	end := big.NewInt(rec.Value - 1)
+	end.Add(end, ipToInt(rec.Start))
+	if end.BitLen() > 32 {
+		return nil, fmt.Errorf("range exceeds the address space: %v+%v", fields[3], fields[4])
+	}
+	rec.CIDRs = Range{start: rec.Start, end: intToIP(end, net.IPv4len)}.CIDRs()
+	return rec, nil
+}
+
+// EachDelegated iterates over the ipv4 and ipv6 records of a RIR statistics exchange file, reading r line by line.
+// 	The version line, summary lines, comments and asn records are skipped.
+func EachDelegated(r io.Reader, iterator func(rec *DelegatedRecord) bool) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "|")
+		// version line starts with the format version, like "2|apnic|20240101|..."
+		if _, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			continue
+		}
+		// summary line, like "apnic|*|ipv4|*|12345|summary"
+		if len(fields) >= 6 && fields[5] == "summary" {
+			continue
+		}
+		if len(fields) < 3 || (fields[2] != "ipv4" && fields[2] != "ipv6") {
+			continue
+		}
+
+		rec, err := parseDelegatedRecord(fields)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		if !iterator(rec) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// DelegatedKey returns the key of a record to group by
+type DelegatedKey func(rec *DelegatedRecord) string
+
+// DelegatedByCountry groups records by country code
+func DelegatedByCountry(rec *DelegatedRecord) string {
+	return rec.CC
+}
+
+// DelegatedByRegistry groups records by registry
+func DelegatedByRegistry(rec *DelegatedRecord) string {
+	return rec.Registry
+}
+
+// DelegatedByStatus groups records by status
+func DelegatedByStatus(rec *DelegatedRecord) string {
+	return rec.Status
+}
+
+// DelegatedByOpaqueID groups records by opaque-id, which identifies the holder of the resources
+func DelegatedByOpaqueID(rec *DelegatedRecord) string {
+	return rec.OpaqueID
+}
+
+// GroupDelegated reads a RIR statistics exchange file and returns the aggregated CIDRs grouped by key,
+// for example, GroupDelegated(r, DelegatedByCountry)["NL"] lists all IPv4 and IPv6 CIDRs of the Netherlands.
+// 	Records with an empty key are ignored.
+func GroupDelegated(r io.Reader, key DelegatedKey) (map[string][]*CIDR, error) {
+	groups := make(map[string][]*CIDR)
+	err := EachDelegated(r, func(rec *DelegatedRecord) bool {
+		if k := key(rec); k != "" {
+			groups[k] = append(groups[k], rec.CIDRs...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	for k, cs := range groups {
+		groups[k] = Aggregate(cs)
+	}
+	return groups, nil
+}
@@ -0,0 +1,89 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const delegatedSample = `# comment line
+2.3|ripencc|1700000000|8|19830705|20231114|+0100
+ripencc|*|asn|*|2|summary
+ripencc|*|ipv4|*|4|summary
+ripencc|*|ipv6|*|2|summary
+ripencc|NL|asn|1101|1|19930901|allocated|a1b2c3
+ripencc|NL|ipv4|192.16.192.0|512|19930901|allocated|a1b2c3
+ripencc|NL|ipv4|192.16.194.0|256|19930901|assigned|a1b2c3
+ripencc|DE|ipv4|194.0.0.0|768|19940101|allocated|d4e5f6
+ripencc|ZZ|ipv4|2.56.0.0|1024||available
+ripencc|NL|ipv6|2001:610::|32|19990819|allocated|a1b2c3
+ripencc|DE|ipv6|2001:638::|32|19990819|allocated|d4e5f6
+`
+
+func TestEachDelegated(t *testing.T) {
+	var recs []*DelegatedRecord
+	err := EachDelegated(strings.NewReader(delegatedSample), func(rec *DelegatedRecord) bool {
+		recs = append(recs, rec)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(recs))
+
+	assert.Equal(t, "ripencc", recs[2].Registry)
+	assert.Equal(t, "DE", recs[2].CC)
+	assert.Equal(t, "ipv4", recs[2].Type)
+	assert.Equal(t, int64(768), recs[2].Value)
+	assert.Equal(t, "allocated", recs[2].Status)
+	assert.Equal(t, "d4e5f6", recs[2].OpaqueID)
+	assert.Equal(t, []string{"194.0.0.0/23", "194.0.2.0/24"}, cidrStrings(recs[2].CIDRs))
+
+	assert.Equal(t, "", recs[3].Date)
+	assert.Equal(t, "", recs[3].OpaqueID)
+	assert.Equal(t, []string{"2001:610::/32"}, cidrStrings(recs[4].CIDRs))
+
+	n := 0
+	err = EachDelegated(strings.NewReader(delegatedSample), func(rec *DelegatedRecord) bool {
+		n++
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestEachDelegated_Error(t *testing.T) {
+	tests := []string{
+		"ripencc|NL|ipv4|192.16.192.0|0|19930901|allocated",
+		"ripencc|NL|ipv4|255.255.255.0|512|19930901|allocated",
+		"ripencc|NL|ipv4|2001:610::|512|19930901|allocated",
+		"ripencc|NL|ipv6|2001:610::1|32|19990819|allocated",
+		"ripencc|NL|ipv6|2001:610::",
+	}
+	for _, test := range tests {
+		err := EachDelegated(strings.NewReader("# header\n"+test), func(rec *DelegatedRecord) bool {
+			return true
+		})
+		assert.Error(t, err, test)
+		assert.Equal(t, true, strings.HasPrefix(err.Error(), "line 2:"), err.Error())
+	}
+}
+
+func TestGroupDelegated(t *testing.T) {
+	groups, err := GroupDelegated(strings.NewReader(delegatedSample), DelegatedByCountry)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.16.192.0/23", "192.16.194.0/24", "2001:610::/32"}, cidrStrings(groups["NL"]))
+	assert.Equal(t, []string{"194.0.0.0/23", "194.0.2.0/24", "2001:638::/32"}, cidrStrings(groups["DE"]))
+	assert.Equal(t, []string{"2.56.0.0/22"}, cidrStrings(groups["ZZ"]))
+
+	groups, err = GroupDelegated(strings.NewReader(delegatedSample), DelegatedByStatus)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.16.194.0/24"}, cidrStrings(groups["assigned"]))
+
+	groups, err = GroupDelegated(strings.NewReader(delegatedSample), DelegatedByOpaqueID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, []string{"192.16.192.0/23", "192.16.194.0/24", "2001:610::/32"}, cidrStrings(groups["a1b2c3"]))
+
+	groups, err = GroupDelegated(strings.NewReader(delegatedSample), DelegatedByRegistry)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, len(groups["ripencc"]))
+}
@@ -0,0 +1,189 @@
+package cidr
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// DHCPSubnet describes a DHCP subnet to generate server configurations for
+type DHCPSubnet struct {
+	CIDR *CIDR
+	// ID of the subnet, required by Kea, omitted if zero
+	ID int
+	// Gateway of the subnet, excluded from pools, used as the routers option for IPv4
+	Gateway string
+	// Exclusions are IPs, ranges or CIDRs excluded from pools, see ParseRange
+	Exclusions []string
+	// DNSServers of the subnet, omitted if empty
+	DNSServers []string
+	// DomainName of the subnet, used as the domain search list for IPv6, omitted if empty
+	DomainName string
+	// LeaseTime in seconds, omitted if zero
+	LeaseTime int
+	// Options are extra options by name, the value is written as it is
+	Options map[string]string
+}
+
+// usableRange returns the range of IPs which can be assigned to hosts,
+// the network and broadcast addresses of IPv4, and the subnet-router anycast address of IPv6 are excluded
+func (s DHCPSubnet) usableRange() Range {
+	r := cidrRange(s.CIDR)
+	ones, bits := s.CIDR.ipNet.Mask.Size()
+	if bits-ones >= 2 {
+		r.start = IPIncr2(r.start)
+		if bits == 32 {
+			r.end = IPDecr2(r.end)
+		}
+	} else if bits == 128 && ones == 127 {
+		r.start = IPIncr2(r.start)
+	}
+	return r
+}
+
+// Pools returns the ranges of the subnet available for dynamic assignment,
+// that is the usable range minus the gateway and exclusions
+func (s DHCPSubnet) Pools() ([]*Range, error) {
+	if s.CIDR == nil {
+		return nil, fmt.Errorf("cidr is required")
+	}
+
+	var excluded []Range
+	exclusions := s.Exclusions
+	if s.Gateway != "" {
+		exclusions = append([]string{s.Gateway}, exclusions...)
+	}
+	for _, e := range exclusions {
+		r, err := ParseRange(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion %v: %v", e, err)
+		}
+		if r.IsIPv4() != s.CIDR.IsIPv4() {
+			return nil, fmt.Errorf("exclusion %v is not the same family as the subnet", e)
+		}
+		excluded = append(excluded, *r)
+	}
+
+	var pools []*Range
+	for _, r := range subtractRanges([]Range{s.usableRange()}, mergeRanges(excluded)) {
+		r := r
+		pools = append(pools, &r)
+	}
+	return pools, nil
+}
+
+// extraOptionNames returns the names of the extra options in ascending order
+func (s DHCPSubnet) extraOptionNames() []string {
+	names := make([]string, 0, len(s.Options))
+	for name := range s.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DhcpdConfig returns the ISC dhcpd "subnet" (IPv4) or "subnet6" (IPv6) declaration of the subnet
+func (s DHCPSubnet) DhcpdConfig() (string, error) {
+	pools, err := s.Pools()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if s.CIDR.IsIPv4() {
+		_, _ = fmt.Fprintf(&sb, "subnet %v netmask %v {\n", s.CIDR.Network(), net.IP(s.CIDR.Mask()))
+		for _, p := range pools {
+			_, _ = fmt.Fprintf(&sb, "\trange %v %v;\n", p.start, p.end)
+		}
+		if s.Gateway != "" {
+			_, _ = fmt.Fprintf(&sb, "\toption routers %v;\n", s.Gateway)
+		}
+		if len(s.DNSServers) > 0 {
+			_, _ = fmt.Fprintf(&sb, "\toption domain-name-servers %v;\n", strings.Join(s.DNSServers, ", "))
+		}
+		if s.DomainName != "" {
+			_, _ = fmt.Fprintf(&sb, "\toption domain-name %q;\n", s.DomainName)
+		}
+	} else {
+		_, _ = fmt.Fprintf(&sb, "subnet6 %v {\n", s.CIDR.String())
+		for _, p := range pools {
+			_, _ = fmt.Fprintf(&sb, "\trange6 %v %v;\n", p.start, p.end)
+		}
+		if len(s.DNSServers) > 0 {
+			_, _ = fmt.Fprintf(&sb, "\toption dhcp6.name-servers %v;\n", strings.Join(s.DNSServers, ", "))
+		}
+		if s.DomainName != "" {
+			_, _ = fmt.Fprintf(&sb, "\toption dhcp6.domain-search %q;\n", s.DomainName)
+		}
+	}
+	if s.LeaseTime > 0 {
+		_, _ = fmt.Fprintf(&sb, "\tdefault-lease-time %d;\n", s.LeaseTime)
+	}
+	for _, name := range s.extraOptionNames() {
+		_, _ = fmt.Fprintf(&sb, "\toption %v %v;\n", name, s.Options[name])
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// KeaPool is a pool of a Kea subnet
+type KeaPool struct {
+	Pool string `json:"pool"`
+}
+
+// KeaOption is an option-data entry of a Kea subnet
+type KeaOption struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+// KeaSubnet is an entry of the Kea DHCPv4 "subnet4" or DHCPv6 "subnet6" list
+type KeaSubnet struct {
+	ID            int         `json:"id,omitempty"`
+	Subnet        string      `json:"subnet"`
+	Pools         []KeaPool   `json:"pools"`
+	OptionData    []KeaOption `json:"option-data,omitempty"`
+	ValidLifetime int         `json:"valid-lifetime,omitempty"`
+}
+
+// KeaSubnet returns the Kea configuration of the subnet, which can be marshaled to JSON
+// and put into the "subnet4" (IPv4) or "subnet6" (IPv6) list
+func (s DHCPSubnet) KeaSubnet() (*KeaSubnet, error) {
+	pools, err := s.Pools()
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeaSubnet{
+		ID:            s.ID,
+		Subnet:        s.CIDR.String(),
+		Pools:         make([]KeaPool, 0, len(pools)),
+		ValidLifetime: s.LeaseTime,
+	}
+	for _, p := range pools {
+		ks.Pools = append(ks.Pools, KeaPool{Pool: p.start.String() + " - " + p.end.String()})
+	}
+	if s.CIDR.IsIPv4() {
+		if s.Gateway != "" {
+			ks.OptionData = append(ks.OptionData, KeaOption{Name: "routers", Data: s.Gateway})
+		}
+		if len(s.DNSServers) > 0 {
+			ks.OptionData = append(ks.OptionData, KeaOption{Name: "domain-name-servers", Data: strings.Join(s.DNSServers, ", ")})
+		}
+		if s.DomainName != "" {
+			ks.OptionData = append(ks.OptionData, KeaOption{Name: "domain-name", Data: s.DomainName})
+		}
+	} else {
+		if len(s.DNSServers) > 0 {
+			ks.OptionData = append(ks.OptionData, KeaOption{Name: "dns-servers", Data: strings.Join(s.DNSServers, ", ")})
+		}
+		if s.DomainName != "" {
+			ks.OptionData = append(ks.OptionData, KeaOption{Name: "domain-search", Data: s.DomainName})
+		}
+	}
+	for _, name := range s.extraOptionNames() {
+		ks.OptionData = append(ks.OptionData, KeaOption{Name: name, Data: s.Options[name]})
+	}
+	return ks, nil
+}
@@ -0,0 +1,100 @@
+package cidr
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDHCPSubnet_Pools(t *testing.T) {
+	s := DHCPSubnet{
+		CIDR:       ParseNoError("192.168.1.0/24"),
+		Gateway:    "192.168.1.1",
+		Exclusions: []string{"192.168.1.100-192.168.1.109", "192.168.1.240/28", "10.0.0.1"},
+	}
+	pools, err := s.Pools()
+	assert.NoError(t, err)
+	var arr []string
+	for _, p := range pools {
+		arr = append(arr, p.String())
+	}
+	assert.Equal(t, []string{"192.168.1.2-192.168.1.99", "192.168.1.110-192.168.1.239"}, arr)
+
+	s = DHCPSubnet{CIDR: ParseNoError("192.168.1.0/31")}
+	pools, _ = s.Pools()
+	assert.Equal(t, "192.168.1.0-192.168.1.1", pools[0].String())
+
+	s = DHCPSubnet{CIDR: ParseNoError("2001:db8::/64")}
+	pools, _ = s.Pools()
+	assert.Equal(t, "2001:db8::1-2001:db8::ffff:ffff:ffff:ffff", pools[0].String())
+
+	s = DHCPSubnet{CIDR: ParseNoError("192.168.1.0/24"), Exclusions: []string{"2001:db8::1"}}
+	_, err = s.Pools()
+	assert.Error(t, err)
+	s = DHCPSubnet{CIDR: ParseNoError("192.168.1.0/24"), Exclusions: []string{"invalid"}}
+	_, err = s.Pools()
+	assert.Error(t, err)
+}
+
+func TestDHCPSubnet_DhcpdConfig(t *testing.T) {
+	s := DHCPSubnet{
+		CIDR:       ParseNoError("192.168.1.0/24"),
+		Gateway:    "192.168.1.1",
+		Exclusions: []string{"192.168.1.100-192.168.1.109"},
+		DNSServers: []string{"192.168.1.2", "192.168.1.3"},
+		DomainName: "example.com",
+		LeaseTime:  3600,
+		Options:    map[string]string{"ntp-servers": "192.168.1.4"},
+	}
+	conf, err := s.DhcpdConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, `subnet 192.168.1.0 netmask 255.255.255.0 {
+	range 192.168.1.2 192.168.1.99;
+	range 192.168.1.110 192.168.1.254;
+	option routers 192.168.1.1;
+	option domain-name-servers 192.168.1.2, 192.168.1.3;
+	option domain-name "example.com";
+	default-lease-time 3600;
+	option ntp-servers 192.168.1.4;
+}
+`, conf)
+
+	s = DHCPSubnet{
+		CIDR:       ParseNoError("2001:db8::/64"),
+		Exclusions: []string{"2001:db8::/120"},
+		DNSServers: []string{"2001:db8::53"},
+	}
+	conf, err = s.DhcpdConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, `subnet6 2001:db8::/64 {
+	range6 2001:db8::100 2001:db8::ffff:ffff:ffff:ffff;
+	option dhcp6.name-servers 2001:db8::53;
+}
+`, conf)
+}
+
+func TestDHCPSubnet_KeaSubnet(t *testing.T) {
+	s := DHCPSubnet{
+		CIDR:       ParseNoError("192.168.1.0/24"),
+		ID:         1,
+		Gateway:    "192.168.1.1",
+		Exclusions: []string{"192.168.1.100-192.168.1.109"},
+		DNSServers: []string{"192.168.1.2"},
+		LeaseTime:  3600,
+	}
+	ks, err := s.KeaSubnet()
+	assert.NoError(t, err)
+	data, _ := json.Marshal(ks)
+	assert.Equal(t, `{"id":1,"subnet":"192.168.1.0/24","pools":[{"pool":"192.168.1.2 - 192.168.1.99"},{"pool":"192.168.1.110 - 192.168.1.254"}],`+
+		`"option-data":[{"name":"routers","data":"192.168.1.1"},{"name":"domain-name-servers","data":"192.168.1.2"}],"valid-lifetime":3600}`, string(data))
+
+	s = DHCPSubnet{
+		CIDR:       ParseNoError("2001:db8::/64"),
+		DomainName: "example.com",
+	}
+	ks, err = s.KeaSubnet()
+	assert.NoError(t, err)
+	data, _ = json.Marshal(ks)
+	assert.Equal(t, `{"subnet":"2001:db8::/64","pools":[{"pool":"2001:db8::1 - 2001:db8::ffff:ffff:ffff:ffff"}],`+
+		`"option-data":[{"name":"domain-search","data":"example.com"}]}`, string(data))
+}
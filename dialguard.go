@@ -0,0 +1,212 @@
+package cidr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrDialDenied is the error wrapped by DialGuard when a connection is denied
+var ErrDialDenied = errors.New("dial denied")
+
+// defaultDialDeny are the non-public networks, see DefaultDialDeny
+var defaultDialDeny = []string{
+	"0.0.0.0/8",       // this network
+	"10.0.0.0/8",      // private
+	"100.64.0.0/10",   // carrier-grade NAT
+	"127.0.0.0/8",     // loopback
+	"169.254.0.0/16",  // link-local, cloud metadata 169.254.169.254 included
+	"172.16.0.0/12",   // private
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"192.88.99.0/24",  // 6to4 relay anycast
+	"192.168.0.0/16",  // private
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"224.0.0.0/4",     // multicast
+	"240.0.0.0/4",     // reserved, broadcast included
+	"::/128",          // unspecified
+	"::1/128",         // loopback
+	"64:ff9b:1::/48",  // local-use NAT64
+	"100::/64",        // discard-only
+	"2001:db8::/32",   // documentation
+	"fc00::/7",        // unique local, cloud metadata fd00:ec2::254 included
+	"fe80::/10",       // link-local
+	"fec0::/10",       // site-local
+	"ff00::/8",        // multicast
+}
+
+// DefaultDialDeny returns the networks denied by default: loopback, private, link-local (cloud metadata included),
+// carrier-grade NAT, multicast, documentation and other reserved networks of both families
+func DefaultDialDeny() []*CIDR {
+	cs := make([]*CIDR, 0, len(defaultDialDeny))
+	for _, s := range defaultDialDeny {
+		cs = append(cs, ParseNoError(s))
+	}
+	return cs
+}
+
+// DialGuard denies connections to IPs in a deny list, to protect services fetching user-supplied URLs
+// against SSRF (server-side request forgery).
+// 	The IPs are checked by the Control function of a net.Dialer, after DNS resolution and for every address
+// dialed, so a host name resolving to a denied IP, at first or later (DNS rebinding), can not bypass the guard.
+// IPv4 addresses embedded in IPv6 ones (IPv4-mapped, IPv4-compatible, IPv4-translated, NAT64 64:ff9b::/96,
+// 6to4 and Teredo) are checked as well.
+type DialGuard struct {
+	deny  *Trie
+	allow *Trie
+}
+
+// NewDialGuard returns a DialGuard denying IPs in deny, DefaultDialDeny if empty, except those in allow
+func NewDialGuard(deny, allow []*CIDR) *DialGuard {
+	if len(deny) == 0 {
+		deny = DefaultDialDeny()
+	}
+	g := &DialGuard{deny: NewTrie(), allow: NewTrie()}
+	for _, c := range deny {
+		g.deny.Insert(c, nil)
+	}
+	for _, c := range allow {
+		g.allow.Insert(c, nil)
+	}
+	return g
+}
+
+// embeddedIPv4 returns the IPv4 addresses embedded in an IPv6 address
+func embeddedIPv4(ip net.IP) []net.IP {
+	isPrefix := func(prefix ...byte) bool {
+		for i, b := range prefix {
+			if ip[i] != b {
+				return false
+			}
+		}
+		return true
+	}
+
+	zero := make([]byte, 10)
+	switch {
+	case isPrefix(zero...) && ((ip[10] == 0 && ip[11] == 0) || (ip[10] == 0xff && ip[11] == 0xff)):
+		// IPv4-compatible ::a.b.c.d, IPv4-mapped ::ffff:a.b.c.d
+		return []net.IP{ip[12:16]}
+	case isPrefix(append(zero[:8], 0xff, 0xff, 0, 0)...):
+		// IPv4-translated ::ffff:0:a.b.c.d
+		return []net.IP{ip[12:16]}
+	case isPrefix(0, 0x64, 0xff, 0x9b, 0, 0, 0, 0, 0, 0, 0, 0):
+		// NAT64 well-known prefix 64:ff9b::/96
+		return []net.IP{ip[12:16]}
+	case isPrefix(0x20, 0x02):
+		// 6to4 2002:a.b.c.d::/48
+		return []net.IP{ip[2:6]}
+	case isPrefix(0x20, 0x01, 0, 0):
+		// Teredo 2001:0::/32, the server and the obfuscated client
+		client := make(net.IP, net.IPv4len)
+		for i := range client {
+			client[i] = ip[12+i] ^ 0xff
+		}
+		return []net.IP{ip[4:8], client}
+	}
+	return nil
+}
+
+// denied returns the denied CIDR including ip, nil if ip is allowed
+func (g *DialGuard) denied(ip net.IP) *CIDR {
+	if c := g.match(ip); c != nil {
+		return c
+	}
+	if len(ip) == net.IPv6len {
+		for _, v4 := range embeddedIPv4(ip) {
+			if c := g.match(v4); c != nil {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// match returns the CIDR of the deny list including ip, unless allowed
+func (g *DialGuard) match(ip net.IP) *CIDR {
+	var found *CIDR
+	g.deny.eachCovering(ip, len(ip)*8, func(node *trieNode) bool {
+		found = node.cidr
+		return false
+	})
+	if found == nil || g.allow.contains(ip) {
+		return nil
+	}
+	return found
+}
+
+// Check returns an error wrapping ErrDialDenied if ip is denied
+func (g *DialGuard) Check(ip net.IP) error {
+	if ip = normalizeIP(ip); ip == nil {
+		return fmt.Errorf("%w: invalid ip", ErrDialDenied)
+	}
+	if c := g.denied(ip); c != nil {
+		return fmt.Errorf("%w: %v is in %v", ErrDialDenied, ip, c)
+	}
+	return nil
+}
+
+// Control checks the resolved address of a connection, it is the Control function of a net.Dialer
+func (g *DialGuard) Control(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDialDenied, err)
+	}
+	// the zone of an IPv6 link-local address
+	if pos := strings.LastIndexByte(host, '%'); pos != -1 {
+		host = host[:pos]
+	}
+	return g.Check(net.ParseIP(host))
+}
+
+// Dialer returns a copy of base, a zero net.Dialer if nil, whose connections are checked by the guard.
+// 	The Control or ControlContext function of base is called after the check.
+func (g *DialGuard) Dialer(base *net.Dialer) *net.Dialer {
+	d := &net.Dialer{}
+	if base != nil {
+		*d = *base
+	}
+	control := d.Control
+	d.Control = func(network, address string, c syscall.RawConn) error {
+		if err := g.Control(network, address, c); err != nil {
+			return err
+		}
+		if control != nil {
+			return control(network, address, c)
+		}
+		return nil
+	}
+	// Control is ignored when ControlContext is set
+	g.guardControlContext(d)
+	return d
+}
+
+// Transport returns a clone of base, http.DefaultTransport if nil, dialing with the guarded dialer,
+// a net.Dialer of 30 seconds Timeout and KeepAlive like http.DefaultTransport if nil.
+// 	The DialContext of base is replaced, and the Proxy of base is removed, as the guard could only check
+// the address of the proxy and not the requested host. An error is returned if base has a custom TLS dial function,
+// whose connections can not be guarded.
+func (g *DialGuard) Transport(base *http.Transport, dialer *net.Dialer) (*http.Transport, error) {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	if hasDialTLS(base) {
+		return nil, fmt.Errorf("custom tls dial function can not be guarded")
+	}
+	if dialer == nil {
+		dialer = &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+	}
+	t := base.Clone()
+	t.Proxy = nil
+	t.DialContext = g.Dialer(dialer).DialContext
+	return t, nil
+}
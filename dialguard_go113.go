@@ -0,0 +1,11 @@
+//go:build !go1.14
+// +build !go1.14
+
+package cidr
+
+import "net/http"
+
+// hasDialTLS reports whether t dials TLS connections with a custom function
+func hasDialTLS(t *http.Transport) bool {
+	return t.DialTLS != nil
+}
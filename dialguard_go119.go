@@ -0,0 +1,9 @@
+//go:build !go1.20
+// +build !go1.20
+
+package cidr
+
+import "net"
+
+// guardControlContext does nothing, as net.Dialer has no ControlContext before Go 1.20
+func (g *DialGuard) guardControlContext(d *net.Dialer) {}
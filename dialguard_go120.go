@@ -0,0 +1,24 @@
+//go:build go1.20
+// +build go1.20
+
+package cidr
+
+import (
+	"context"
+	"net"
+	"syscall"
+)
+
+// guardControlContext checks the connections of d before its ControlContext function, if any
+func (g *DialGuard) guardControlContext(d *net.Dialer) {
+	control := d.ControlContext
+	if control == nil {
+		return
+	}
+	d.ControlContext = func(ctx context.Context, network, address string, c syscall.RawConn) error {
+		if err := g.Control(network, address, c); err != nil {
+			return err
+		}
+		return control(ctx, network, address, c)
+	}
+}
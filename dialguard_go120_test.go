@@ -0,0 +1,51 @@
+//go:build go1.20
+// +build go1.20
+
+package cidr
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestDialGuard_DialerControlContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	// Control is ignored by net.Dialer when ControlContext is set
+	called := false
+	base := &net.Dialer{ControlContext: func(ctx context.Context, network, address string, c syscall.RawConn) error {
+		called = true
+		return nil
+	}}
+	_, err = NewDialGuard(DefaultDialDeny(), nil).Dialer(base).Dial("tcp", ln.Addr().String())
+	assert.True(t, errors.Is(err, ErrDialDenied))
+	assert.False(t, called)
+
+	transport, err := NewDialGuard(nil, nil).Transport(nil, base)
+	assert.NoError(t, err)
+	_, err = transport.DialContext(context.Background(), "tcp", ln.Addr().String())
+	assert.True(t, errors.Is(err, ErrDialDenied))
+
+	conn, err := NewDialGuard(nil, []*CIDR{ParseNoError("127.0.0.1/32")}).Dialer(base).Dial("tcp", ln.Addr().String())
+	if assert.NoError(t, err) {
+		_ = conn.Close()
+	}
+	assert.True(t, called)
+}
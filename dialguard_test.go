@@ -0,0 +1,117 @@
+package cidr
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDialGuard_Check(t *testing.T) {
+	g := NewDialGuard(nil, nil)
+	for _, ip := range []string{
+		"127.0.0.1", "10.1.2.3", "172.31.255.255", "192.168.1.1", "169.254.169.254", "100.64.0.1",
+		"0.0.0.0", "255.255.255.255", "224.0.0.1",
+		"::1", "::", "fe80::1", "fd00:ec2::254", "ff02::1",
+		"::ffff:127.0.0.1", "::127.0.0.1", "::ffff:0:169.254.169.254", "64:ff9b::a9fe:a9fe",
+		"2002:c0a8:101::1", "2001:0:4136:e378:8000:63bf:f5ff:fffe",
+	} {
+		err := g.Check(net.ParseIP(ip))
+		assert.True(t, errors.Is(err, ErrDialDenied), ip)
+	}
+	for _, ip := range []string{"8.8.8.8", "1.1.1.1", "2001:4860:4860::8888", "::ffff:8.8.8.8", "64:ff9b::808:808",
+		"2002:808:808::1"} {
+		assert.NoError(t, g.Check(net.ParseIP(ip)), ip)
+	}
+	assert.Error(t, g.Check(nil))
+
+	// the Teredo client 192.168.0.1 is obfuscated
+	assert.Error(t, g.Check(net.ParseIP("2001:0:808:808::3f57:fffe")))
+
+	g = NewDialGuard([]*CIDR{ParseNoError("10.0.0.0/8")}, []*CIDR{ParseNoError("10.0.0.5/32")})
+	assert.Error(t, g.Check(net.ParseIP("10.0.0.6")))
+	assert.NoError(t, g.Check(net.ParseIP("10.0.0.5")))
+	assert.NoError(t, g.Check(net.ParseIP("127.0.0.1")))
+
+	assert.Error(t, g.Control("tcp", "10.0.0.6:80", nil))
+	assert.NoError(t, g.Control("tcp", "[fe80::1%eth0]:80", nil))
+	assert.Error(t, g.Control("tcp", "10.0.0.6", nil))
+}
+
+func TestDialGuard_Dialer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	_, err = NewDialGuard(nil, nil).Dialer(nil).Dial("tcp", ln.Addr().String())
+	assert.True(t, errors.Is(err, ErrDialDenied))
+
+	called := false
+	base := &net.Dialer{Control: func(network, address string, c syscall.RawConn) error {
+		called = true
+		return nil
+	}}
+	conn, err := NewDialGuard(nil, []*CIDR{ParseNoError("127.0.0.1/32")}).Dialer(base).Dial("tcp", ln.Addr().String())
+	if assert.NoError(t, err) {
+		_ = conn.Close()
+	}
+	assert.True(t, called)
+}
+
+func TestDialGuard_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport, err := NewDialGuard(nil, nil).Transport(nil, nil)
+	assert.NoError(t, err)
+	client := &http.Client{Transport: transport}
+	_, err = client.Get(server.URL)
+	assert.True(t, errors.Is(err, ErrDialDenied))
+
+	// the dialer of the caller is used
+	called := false
+	dialer := &net.Dialer{Timeout: time.Second, Control: func(network, address string, c syscall.RawConn) error {
+		called = true
+		return nil
+	}}
+	transport, err = NewDialGuard(nil, []*CIDR{ParseNoError("127.0.0.0/8")}).Transport(nil, dialer)
+	assert.NoError(t, err)
+	client = &http.Client{Transport: transport}
+	resp, err := client.Get(server.URL)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.True(t, called)
+
+	// a proxy would be dialed instead of the requested host
+	base := &http.Transport{Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: "127.0.0.1:3128"})}
+	transport, err = NewDialGuard(nil, nil).Transport(base, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, transport.Proxy)
+	assert.NotNil(t, base.Proxy)
+
+	base = &http.Transport{DialTLS: func(network, addr string) (net.Conn, error) {
+		return nil, errors.New("unexpected")
+	}}
+	_, err = NewDialGuard(nil, nil).Transport(base, nil)
+	assert.Error(t, err)
+}
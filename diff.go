@@ -0,0 +1,27 @@
+package cidr
+
+import "bytes"
+
+// Diff compares the address space of two lists of CIDRs, returning the minimal lists of CIDRs
+// only in a, only in b and in both, in ascending order.
+// 	It is the representation independent comparison of prefix lists, like an allowlist before and after a migration.
+func Diff(a, b []*CIDR) (onlyA, onlyB, both []*CIDR) {
+	ra, rb := cidrRanges(a), cidrRanges(b)
+	return rangesToCIDRs(subtractRanges(ra, rb)), rangesToCIDRs(subtractRanges(rb, ra)), rangesToCIDRs(intersectRanges(ra, rb))
+}
+
+// Equivalent reports whether a and b cover exactly the same IPs, regardless of ordering, duplicates,
+// or how the address space is split into CIDRs.
+// 	For example, "10.0.0.0/23" is equivalent to "10.0.1.0/24" and "10.0.0.0/24".
+func Equivalent(a, b []*CIDR) bool {
+	ra, rb := cidrRanges(a), cidrRanges(b)
+	if len(ra) != len(rb) {
+		return false
+	}
+	for i := range ra {
+		if !bytes.Equal(ra[i].start, rb[i].start) || !bytes.Equal(ra[i].end, rb[i].end) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := []*CIDR{
+		ParseNoError("10.0.0.0/16"),
+		ParseNoError("192.168.0.0/24"),
+		ParseNoError("2001:db8::/32"),
+	}
+	b := []*CIDR{
+		ParseNoError("10.0.128.0/17"),
+		ParseNoError("10.1.0.0/24"),
+		ParseNoError("192.168.0.0/25"),
+		ParseNoError("192.168.0.128/25"),
+		ParseNoError("2001:db8:8000::/33"),
+	}
+	onlyA, onlyB, both := Diff(a, b)
+	assert.Equal(t, []string{"10.0.0.0/17", "2001:db8::/33"}, cidrStrings(onlyA))
+	assert.Equal(t, []string{"10.1.0.0/24"}, cidrStrings(onlyB))
+	assert.Equal(t, []string{"10.0.128.0/17", "192.168.0.0/24", "2001:db8:8000::/33"}, cidrStrings(both))
+
+	onlyA, onlyB, both = Diff(a, nil)
+	assert.Equal(t, []string{"10.0.0.0/16", "192.168.0.0/24", "2001:db8::/32"}, cidrStrings(onlyA))
+	assert.Equal(t, []string{}, cidrStrings(onlyB))
+	assert.Equal(t, []string{}, cidrStrings(both))
+}
+
+func TestEquivalent(t *testing.T) {
+	a := []*CIDR{
+		ParseNoError("10.0.0.0/23"),
+		ParseNoError("2001:db8::/32"),
+	}
+	b := []*CIDR{
+		ParseNoError("2001:db8::/33"),
+		ParseNoError("10.0.1.0/24"),
+		ParseNoError("2001:db8:8000::/33"),
+		ParseNoError("10.0.0.0/24"),
+		ParseNoError("10.0.0.128/25"),
+	}
+	assert.True(t, Equivalent(a, b))
+	assert.True(t, Equivalent(nil, nil))
+	assert.False(t, Equivalent(a, b[1:]))
+	assert.False(t, Equivalent(a, append(b, ParseNoError("10.0.2.0/32"))))
+}
@@ -0,0 +1,227 @@
+package cidr
+
+import (
+	"container/heap"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// HeavyHitter is a prefix of a HeavyHitters report, whose true weight is between Count-Error and Count
+type HeavyHitter struct {
+	CIDR  *CIDR
+	Count uint64
+	Error uint64
+}
+
+// hhEntry is a monitored prefix of a level
+type hhEntry struct {
+	key   string
+	cidr  *CIDR
+	count uint64
+	err   uint64
+	index int
+}
+
+// hhLevel is the Space-Saving summary of a prefix length, a min heap of monitored prefixes by count
+type hhLevel struct {
+	ones    int
+	entries []*hhEntry
+	keys    map[string]*hhEntry
+}
+
+func (l *hhLevel) Len() int           { return len(l.entries) }
+func (l *hhLevel) Less(i, j int) bool { return l.entries[i].count < l.entries[j].count }
+func (l *hhLevel) Swap(i, j int) {
+	l.entries[i], l.entries[j] = l.entries[j], l.entries[i]
+	l.entries[i].index, l.entries[j].index = i, j
+}
+func (l *hhLevel) Push(x interface{}) {
+	e := x.(*hhEntry)
+	e.index = len(l.entries)
+	l.entries = append(l.entries, e)
+}
+func (l *hhLevel) Pop() interface{} {
+	e := l.entries[len(l.entries)-1]
+	l.entries = l.entries[:len(l.entries)-1]
+	return e
+}
+
+// add adds weight to the prefix of ip, replacing the prefix of the minimum count if the level is full
+func (l *hhLevel) add(ip net.IP, weight uint64, capacity int) {
+	c := newCIDR(ip, l.ones)
+	key := c.String()
+	if e, ok := l.keys[key]; ok {
+		e.count += weight
+		heap.Fix(l, e.index)
+		return
+	}
+	if len(l.entries) < capacity {
+		e := &hhEntry{key: key, cidr: c, count: weight}
+		l.keys[key] = e
+		heap.Push(l, e)
+		return
+	}
+
+	// the new prefix inherits the count of the evicted one as its error
+	e := l.entries[0]
+	delete(l.keys, e.key)
+	e.key, e.cidr, e.err = key, c, e.count
+	e.count += weight
+	l.keys[key] = e
+	heap.Fix(l, 0)
+}
+
+// HeavyHitters finds the prefixes of most weight in a stream of IPs, like the networks generating most traffic
+// in flow logs, at several prefix lengths of each family.
+// 	Each prefix length is summarized by the Space-Saving algorithm with a bounded number of counters,
+// the count of a reported prefix is never underestimated, and any prefix of a weight greater than
+// Total/capacity is reported.
+// HeavyHitters is not safe for concurrent use.
+type HeavyHitters struct {
+	capacity int
+	v4, v6   []*hhLevel
+	total    uint64
+}
+
+// NewHeavyHitters returns a HeavyHitters of capacity counters per prefix length,
+// at ipv4Levels for IPv4, like 8, 16, 24 and 32, and ipv6Levels for IPv6, like 32, 48, 64 and 128
+func NewHeavyHitters(capacity int, ipv4Levels, ipv6Levels []int) (*HeavyHitters, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid capacity: %d", capacity)
+	}
+	h := &HeavyHitters{capacity: capacity}
+	var err error
+	if h.v4, err = newHHLevels(ipv4Levels, 8*net.IPv4len); err != nil {
+		return nil, err
+	}
+	if h.v6, err = newHHLevels(ipv6Levels, 8*net.IPv6len); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func newHHLevels(levels []int, bits int) ([]*hhLevel, error) {
+	sorted := make([]int, len(levels))
+	copy(sorted, levels)
+	sort.Ints(sorted)
+	var arr []*hhLevel
+	for i, ones := range sorted {
+		if ones < 0 || ones > bits {
+			return nil, fmt.Errorf("invalid prefix length: %d", ones)
+		}
+		if i > 0 && ones == sorted[i-1] {
+			continue
+		}
+		arr = append(arr, &hhLevel{ones: ones, keys: map[string]*hhEntry{}})
+	}
+	return arr, nil
+}
+
+// Add adds weight to ip, like the bytes of a flow of ip, IPs of a family without levels are only counted by Total
+func (h *HeavyHitters) Add(ip net.IP, weight uint64) error {
+	ip = normalizeIP(ip)
+	if ip == nil {
+		return fmt.Errorf("invalid ip")
+	}
+	h.total += weight
+	levels := h.v6
+	if len(ip) == net.IPv4len {
+		levels = h.v4
+	}
+	for _, l := range levels {
+		l.add(ip, weight, h.capacity)
+	}
+	return nil
+}
+
+// Total returns the total weight added
+func (h *HeavyHitters) Total() uint64 {
+	return h.total
+}
+
+// Report returns the prefixes of a count of at least threshold at every level, IPv4 first,
+// then from the least specific level to the most specific one, and then by descending count.
+// 	The count of a prefix includes the weight of its more specific prefixes, so the weight of a heavy host
+// is counted again by each of its reported ancestors, see ReportHierarchical to count it once.
+func (h *HeavyHitters) Report(threshold uint64) []HeavyHitter {
+	var arr []HeavyHitter
+	for _, l := range append(append([]*hhLevel{}, h.v4...), h.v6...) {
+		var level []HeavyHitter
+		for _, e := range l.entries {
+			if e.count >= threshold {
+				level = append(level, HeavyHitter{CIDR: e.cidr, Count: e.count, Error: e.err})
+			}
+		}
+		sortHeavyHitters(level)
+		arr = append(arr, level...)
+	}
+	return arr
+}
+
+// ReportHierarchical returns the hierarchical heavy hitters of a conditioned count of at least threshold,
+// in the order of Report.
+// 	The conditioned count of a prefix is its count minus the counts of its most general reported more specific
+// prefixes, so the weight of a reported prefix is not reported again by its ancestors, like a heavy /24
+// only reported when its weight without its heavy hosts is heavy.
+// As the counts are estimates, the lower bounds of the more specific prefixes are subtracted, and their errors
+// are added to the error, so the true conditioned weight is between Count-Error and Count.
+func (h *HeavyHitters) ReportHierarchical(threshold uint64) []HeavyHitter {
+	var arr []HeavyHitter
+	for _, levels := range [][]*hhLevel{h.v4, h.v6} {
+		// the reported prefixes not covered by another reported prefix, with their raw counts
+		var reported []*hhEntry
+		family := make([][]HeavyHitter, len(levels))
+		for i := len(levels) - 1; i >= 0; i-- {
+			var next []*hhEntry
+			covered := make([]bool, len(reported))
+			for _, e := range levels[i].entries {
+				discount, err := uint64(0), e.err
+				var children []int
+				for j, r := range reported {
+					if e.cidr.ipNet.Contains(r.cidr.ipNet.IP) {
+						discount += r.count - r.err
+						err += r.err
+						children = append(children, j)
+					}
+				}
+				count := uint64(0)
+				if e.count > discount {
+					count = e.count - discount
+				}
+				if count < threshold {
+					continue
+				}
+				if err > count {
+					err = count
+				}
+				family[i] = append(family[i], HeavyHitter{CIDR: e.cidr, Count: count, Error: err})
+				next = append(next, e)
+				for _, j := range children {
+					covered[j] = true
+				}
+			}
+			for j, r := range reported {
+				if !covered[j] {
+					next = append(next, r)
+				}
+			}
+			reported = next
+			sortHeavyHitters(family[i])
+		}
+		for _, level := range family {
+			arr = append(arr, level...)
+		}
+	}
+	return arr
+}
+
+// sortHeavyHitters sorts the heavy hitters of a level by descending count, then by ascending network
+func sortHeavyHitters(level []HeavyHitter) {
+	sort.Slice(level, func(i, j int) bool {
+		if level[i].Count != level[j].Count {
+			return level[i].Count > level[j].Count
+		}
+		return IPCompare(level[i].CIDR.ipNet.IP, level[j].CIDR.ipNet.IP) < 0
+	})
+}
@@ -0,0 +1,133 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"net"
+	"testing"
+)
+
+func TestHeavyHitters(t *testing.T) {
+	h, err := NewHeavyHitters(4, []int{32, 16, 24}, []int{48})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	add := func(ip string, weight uint64) {
+		assert.NoError(t, h.Add(net.ParseIP(ip), weight))
+	}
+	// a heavy host, a heavy /24 of many small hosts, and noise
+	add("10.0.0.1", 1000)
+	for i := 1; i <= 50; i++ {
+		add(net.IPv4(10, 1, 2, byte(i)).String(), 20)
+	}
+	for i := 1; i <= 20; i++ {
+		add(net.IPv4(192, 168, byte(i), 1).String(), 5)
+	}
+	add("2001:db8:1::1", 300)
+	add("2001:db8:1:2::1", 300)
+	add("2001:db8:2::1", 10)
+
+	assert.Equal(t, uint64(1000+50*20+20*5+610), h.Total())
+
+	var got []string
+	for _, hh := range h.Report(500) {
+		got = append(got, hh.CIDR.String())
+		assert.True(t, hh.Count >= 500)
+	}
+	assert.Equal(t, []string{
+		"10.0.0.0/16", "10.1.0.0/16",
+		"10.0.0.0/24", "10.1.2.0/24",
+		"10.0.0.1/32",
+		"2001:db8:1::/48",
+	}, got)
+
+	report := h.Report(500)
+	assert.Equal(t, HeavyHitter{CIDR: ParseNoError("10.0.0.1/32"), Count: 1000}, report[4])
+	assert.Equal(t, uint64(600), report[5].Count)
+
+	// the count of evicted prefixes is inherited as the error
+	var found *HeavyHitter
+	for _, hh := range h.Report(0) {
+		if hh.CIDR.String() == "192.168.20.0/24" {
+			hh := hh
+			found = &hh
+		}
+	}
+	if assert.NotNil(t, found) {
+		assert.Equal(t, uint64(50), found.Count)
+		assert.Equal(t, uint64(45), found.Error)
+	}
+
+	// the heavy host is not counted again by its /24 and /16, nor the heavy /24 by its /16
+	assert.Equal(t, []HeavyHitter{
+		{CIDR: ParseNoError("10.1.2.0/24"), Count: 1000},
+		{CIDR: ParseNoError("10.0.0.1/32"), Count: 1000},
+		{CIDR: ParseNoError("2001:db8:1::/48"), Count: 600},
+	}, h.ReportHierarchical(500))
+
+	// a /24 is reported by the weight of its hosts other than the heavy one
+	h, _ = NewHeavyHitters(100, []int{24, 32}, nil)
+	add("10.0.0.1", 600)
+	for i := 2; i <= 11; i++ {
+		add(net.IPv4(10, 0, 0, byte(i)).String(), 50)
+	}
+	assert.Equal(t, []HeavyHitter{
+		{CIDR: ParseNoError("10.0.0.0/24"), Count: 500},
+		{CIDR: ParseNoError("10.0.0.1/32"), Count: 600},
+	}, h.ReportHierarchical(400))
+	assert.Equal(t, []HeavyHitter{{CIDR: ParseNoError("10.0.0.1/32"), Count: 600}}, h.ReportHierarchical(600))
+
+	assert.Error(t, h.Add(nil, 1))
+	_, err = NewHeavyHitters(0, []int{24}, nil)
+	assert.Error(t, err)
+	_, err = NewHeavyHitters(10, []int{33}, nil)
+	assert.Error(t, err)
+	_, err = NewHeavyHitters(10, nil, []int{-1})
+	assert.Error(t, err)
+}
+
+func TestHeavyHitters_ReportHierarchicalBound(t *testing.T) {
+	h, _ := NewHeavyHitters(16, []int{8, 16, 24, 32}, nil)
+	r := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(r, 1.2, 1, 255)
+	weights := map[string]uint64{}
+	for i := 0; i < 20000; i++ {
+		ip := net.IPv4(10, byte(zipf.Uint64()), byte(zipf.Uint64()), byte(zipf.Uint64()))
+		weight := uint64(r.Intn(10) + 1)
+		assert.NoError(t, h.Add(ip, weight))
+		for _, ones := range []int{8, 16, 24, 32} {
+			weights[newCIDR(ip.To4(), ones).String()] += weight
+		}
+	}
+
+	report := h.ReportHierarchical(h.Total() / 50)
+	if !assert.True(t, len(report) > 1) {
+		return
+	}
+	discounted := false
+	for i, hh := range report {
+		// the true weight minus the true weights of the most general reported more specific prefixes
+		ones, _ := hh.CIDR.ipNet.Mask.Size()
+		weight := weights[hh.CIDR.String()]
+		for j, child := range report {
+			childOnes, _ := child.CIDR.ipNet.Mask.Size()
+			if j == i || childOnes <= ones || !hh.CIDR.ipNet.Contains(child.CIDR.ipNet.IP) {
+				continue
+			}
+			general := true
+			for k, other := range report {
+				otherOnes, _ := other.CIDR.ipNet.Mask.Size()
+				if k != j && otherOnes > ones && otherOnes < childOnes && other.CIDR.ipNet.Contains(child.CIDR.ipNet.IP) {
+					general = false
+				}
+			}
+			if general {
+				weight -= weights[child.CIDR.String()]
+				discounted = true
+			}
+		}
+		assert.True(t, hh.Count-hh.Error <= weight && weight <= hh.Count, "%v %d-%d %d", hh.CIDR, hh.Count, hh.Error, weight)
+	}
+	assert.True(t, discounted)
+}
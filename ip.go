@@ -33,6 +33,35 @@ func toIPv4Broadcast(ip net.IP) {
 	fillIPBytes(ip, 10, 15, 0xFF)
 }
 
+// normalizeIP returns ip in its shortest form, 4 bytes for IPv4 (including IPv4-mapped) and 16 bytes for IPv6,
+// or nil if ip is invalid
+func normalizeIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	if len(ip) == net.IPv6len {
+		return ip
+	}
+	return nil
+}
+
+// parseIP parses s as an IP address and returns it normalized
+func parseIP(s string) net.IP {
+	return normalizeIP(net.ParseIP(s))
+}
+
+func ipBit(ip []byte, i int) byte {
+	return (ip[i/8] >> uint(7-i%8)) & 1
+}
+
+func setIPBit(ip []byte, i int, v byte) {
+	if v == 0 {
+		ip[i/8] &^= 1 << uint(7-i%8)
+	} else {
+		ip[i/8] |= 1 << uint(7-i%8)
+	}
+}
+
 // IPIncr ip increase
 func IPIncr(ip net.IP) {
 	if ip == nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
@@ -0,0 +1,67 @@
+package cidr
+
+import (
+	"math/big"
+	"net"
+	"sort"
+)
+
+// IPSet is an immutable set of IPs of both families, stored as sorted and merged ranges.
+// 	IPSet is safe for concurrent use.
+type IPSet struct {
+	ranges []Range
+}
+
+// NewIPSet returns the IPSet of the IPs in cs
+func NewIPSet(cs []*CIDR) *IPSet {
+	return &IPSet{ranges: cidrRanges(cs)}
+}
+
+// newIPSet returns the IPSet of the IPs in rs
+func newIPSet(rs []Range) *IPSet {
+	return &IPSet{ranges: mergeRanges(rs)}
+}
+
+// Contains reports whether ip is in the set
+func (s *IPSet) Contains(ip string) bool {
+	ipObj := parseIP(ip)
+	return ipObj != nil && s.containsIP(ipObj)
+}
+
+// containsIP reports whether ip, which must be normalized, is in the set
+func (s *IPSet) containsIP(ip net.IP) bool {
+	key := Range{start: ip}
+	i := sort.Search(len(s.ranges), func(i int) bool {
+		return compareRange(Range{start: s.ranges[i].end}, key) >= 0
+	})
+	return i < len(s.ranges) && s.ranges[i].containsIP(ip)
+}
+
+// IsEmpty reports whether the set has no IP
+func (s *IPSet) IsEmpty() bool {
+	return len(s.ranges) == 0
+}
+
+// IPCount returns the number of IPs in the set
+func (s *IPSet) IPCount() *big.Int {
+	n := big.NewInt(0)
+	for _, r := range s.ranges {
+		n.Add(n, r.IPCount())
+	}
+	return n
+}
+
+// Ranges returns the minimal list of ranges of the set, in ascending order, IPv4 first
+func (s *IPSet) Ranges() []*Range {
+	rs := make([]*Range, 0, len(s.ranges))
+	for i := range s.ranges {
+		r := s.ranges[i]
+		rs = append(rs, &r)
+	}
+	return rs
+}
+
+// CIDRs returns the minimal list of CIDRs of the set, in ascending order, IPv4 first
+func (s *IPSet) CIDRs() []*CIDR {
+	return rangesToCIDRs(s.ranges)
+}
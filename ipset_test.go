@@ -0,0 +1,35 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIPSet(t *testing.T) {
+	s := NewIPSet([]*CIDR{
+		ParseNoError("2001:db8::/32"),
+		ParseNoError("10.0.1.0/24"),
+		ParseNoError("10.0.0.0/24"),
+		ParseNoError("192.168.1.0/24"),
+	})
+	assert.False(t, s.IsEmpty())
+	assert.True(t, s.Contains("10.0.0.0"))
+	assert.True(t, s.Contains("10.0.1.255"))
+	assert.True(t, s.Contains("::ffff:192.168.1.1"))
+	assert.True(t, s.Contains("2001:db8:ffff::1"))
+	assert.False(t, s.Contains("10.0.2.0"))
+	assert.False(t, s.Contains("9.255.255.255"))
+	assert.False(t, s.Contains("255.255.255.255"))
+	assert.False(t, s.Contains("::a00:1"))
+	assert.False(t, s.Contains("abc"))
+
+	assert.Equal(t, []string{"10.0.0.0/23", "192.168.1.0/24", "2001:db8::/32"}, cidrStrings(s.CIDRs()))
+	rs := s.Ranges()
+	assert.Equal(t, 3, len(rs))
+	assert.Equal(t, "10.0.0.0-10.0.1.255", rs[0].String())
+	assert.Equal(t, "79228162514264337593543951104", s.IPCount().String())
+
+	s = NewIPSet(nil)
+	assert.True(t, s.IsEmpty())
+	assert.False(t, s.Contains("10.0.0.0"))
+}
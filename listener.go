@@ -0,0 +1,108 @@
+package cidr
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// maxRejectedPeers is the maximum number of distinct peers counted by a FilterListener
+const maxRejectedPeers = 1024
+
+// filterRules are the rules of a FilterListener, replaced as a whole
+type filterRules struct {
+	allow *Trie
+	deny  *Trie
+}
+
+// FilterListener is a net.Listener accepting connections only from peers in an allowlist,
+// unless they are in a denylist, the connections of other peers are closed immediately.
+// 	The rules can be replaced at runtime by SetRules, without restarting the listener.
+type FilterListener struct {
+	// rejected is first for the 64-bit alignment of atomic operations
+	rejected uint64
+	net.Listener
+	rules atomic.Value
+	mu    sync.Mutex
+	peers map[string]uint64
+}
+
+// NewFilterListener returns a FilterListener of l, accepting peers in allow and not in deny.
+// 	An empty allow accepts no peer.
+func NewFilterListener(l net.Listener, allow, deny []*CIDR) *FilterListener {
+	f := &FilterListener{Listener: l, peers: map[string]uint64{}}
+	f.SetRules(allow, deny)
+	return f
+}
+
+// SetRules atomically replaces the rules, connections already accepted are not affected
+func (f *FilterListener) SetRules(allow, deny []*CIDR) {
+	rules := &filterRules{allow: NewTrie(), deny: NewTrie()}
+	for _, c := range allow {
+		rules.allow.Insert(c, nil)
+	}
+	for _, c := range deny {
+		rules.deny.Insert(c, nil)
+	}
+	f.rules.Store(rules)
+}
+
+// accepts reports whether the peer of conn is accepted, and returns its IP
+func (f *FilterListener) accepts(conn net.Conn) (net.IP, bool) {
+	var ip net.IP
+	switch addr := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		ip = normalizeIP(addr.IP)
+	case nil:
+	default:
+		ip = parseHostIP(addr.String())
+	}
+	if ip == nil {
+		return nil, false
+	}
+	rules := f.rules.Load().(*filterRules)
+	return ip, !rules.deny.contains(ip) && rules.allow.contains(ip)
+}
+
+// Accept waits for and returns the next connection of an accepted peer
+func (f *FilterListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := f.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		ip, ok := f.accepts(conn)
+		if ok {
+			return conn, nil
+		}
+
+		atomic.AddUint64(&f.rejected, 1)
+		peer := "unknown"
+		if ip != nil {
+			peer = ip.String()
+		}
+		f.mu.Lock()
+		if _, exists := f.peers[peer]; exists || len(f.peers) < maxRejectedPeers {
+			f.peers[peer]++
+		}
+		f.mu.Unlock()
+		_ = conn.Close()
+	}
+}
+
+// Rejected returns the number of rejected connections
+func (f *FilterListener) Rejected() uint64 {
+	return atomic.LoadUint64(&f.rejected)
+}
+
+// RejectedPeers returns the number of rejected connections by peer IP, "unknown" for peers without an IP.
+// 	At most 1024 distinct peers are counted, connections of further peers are only counted by Rejected.
+func (f *FilterListener) RejectedPeers() map[string]uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	peers := make(map[string]uint64, len(f.peers))
+	for k, v := range f.peers {
+		peers[k] = v
+	}
+	return peers
+}
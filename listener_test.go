@@ -0,0 +1,102 @@
+package cidr
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+type fakeConn struct {
+	net.Conn
+	remote net.Addr
+	closed bool
+}
+
+func (c *fakeConn) RemoteAddr() net.Addr { return c.remote }
+func (c *fakeConn) Close() error         { c.closed = true; return nil }
+
+type fakeListener struct {
+	net.Listener
+	conns []net.Conn
+}
+
+func (l *fakeListener) Accept() (net.Conn, error) {
+	if len(l.conns) == 0 {
+		return nil, errors.New("closed")
+	}
+	conn := l.conns[0]
+	l.conns = l.conns[1:]
+	return conn, nil
+}
+
+func fakeTCPConn(ip string) *fakeConn {
+	return &fakeConn{remote: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}}
+}
+
+func TestFilterListener(t *testing.T) {
+	conns := []*fakeConn{
+		fakeTCPConn("10.0.0.1"),
+		fakeTCPConn("10.0.9.1"),
+		fakeTCPConn("192.168.1.1"),
+		fakeTCPConn("10.0.9.1"),
+		{remote: &net.UnixAddr{Name: "/tmp/admin.sock", Net: "unix"}},
+		fakeTCPConn("::ffff:10.0.0.2"),
+	}
+	l := &fakeListener{}
+	for _, c := range conns {
+		l.conns = append(l.conns, c)
+	}
+	f := NewFilterListener(l, []*CIDR{ParseNoError("10.0.0.0/16")}, []*CIDR{ParseNoError("10.0.9.0/24")})
+
+	conn, err := f.Accept()
+	assert.NoError(t, err)
+	assert.Equal(t, conns[0], conn)
+	conn, err = f.Accept()
+	assert.NoError(t, err)
+	assert.Equal(t, conns[5], conn)
+	_, err = f.Accept()
+	assert.Error(t, err)
+
+	assert.False(t, conns[0].closed)
+	assert.True(t, conns[1].closed)
+	assert.True(t, conns[4].closed)
+	assert.Equal(t, uint64(4), f.Rejected())
+	assert.Equal(t, map[string]uint64{"10.0.9.1": 2, "192.168.1.1": 1, "unknown": 1}, f.RejectedPeers())
+
+	// swap the rules
+	l.conns = []net.Conn{fakeTCPConn("10.0.0.1"), fakeTCPConn("192.168.1.1")}
+	f.SetRules([]*CIDR{ParseNoError("192.168.0.0/16")}, nil)
+	conn, err = f.Accept()
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.1.1", conn.RemoteAddr().(*net.TCPAddr).IP.String())
+	assert.Equal(t, uint64(5), f.Rejected())
+}
+
+func TestFilterListener_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	f := NewFilterListener(ln, nil, nil)
+	defer f.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := f.Accept()
+		done <- err
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if assert.NoError(t, err) {
+		// the connection is closed by the listener
+		_, err = conn.Read(make([]byte, 1))
+		assert.Error(t, err)
+		_ = conn.Close()
+	}
+	assert.Equal(t, uint64(1), f.Rejected())
+	assert.Equal(t, map[string]uint64{"127.0.0.1": 1}, f.RejectedPeers())
+
+	_ = f.Close()
+	assert.Error(t, <-done)
+}
@@ -0,0 +1,197 @@
+package cidr
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// AddrMask is an IP address with an arbitrary wildcard mask, as used by Cisco-style ACLs.
+// 	A bit set in the wildcard means "don't care", the bits are not required to be contiguous.
+// For example, "10.1.0.5 0.0.255.0" matches 10.1.0.5, 10.1.1.5, ..., 10.1.255.5,
+// which can not be expressed as a single CIDR.
+type AddrMask struct {
+	ip       net.IP
+	wildcard net.IPMask
+}
+
+// NewAddrMask returns an AddrMask of ip and wildcard, the wildcard must be of the family of ip
+func NewAddrMask(ip, wildcard string) (*AddrMask, error) {
+	ipObj := parseIP(ip)
+	if ipObj == nil {
+		return nil, fmt.Errorf("invalid ip: %v", ip)
+	}
+	wc := net.ParseIP(wildcard)
+	if wc == nil {
+		return nil, fmt.Errorf("invalid wildcard: %v", wildcard)
+	}
+	// the family is the one of ip, an IPv6 wildcard like "::ffff:0:ff" is not IPv4
+	var wcObj net.IP
+	if len(ipObj) == net.IPv4len {
+		wcObj = wc.To4()
+	} else if strings.Contains(wildcard, ":") {
+		wcObj = wc.To16()
+	}
+	if wcObj == nil {
+		return nil, fmt.Errorf("ip and wildcard are not the same family")
+	}
+
+	m := &AddrMask{ip: make(net.IP, len(ipObj)), wildcard: net.IPMask(wcObj)}
+	for i := range ipObj {
+		m.ip[i] = ipObj[i] &^ wcObj[i]
+	}
+	return m, nil
+}
+
+// ParseAddrMask parses s as an ACL address specification, like "10.1.0.5 0.0.255.0",
+// "host 10.1.0.5" or "any" (which is IPv4)
+func ParseAddrMask(s string) (*AddrMask, error) {
+	fields := strings.Fields(s)
+	switch {
+	case len(fields) == 1 && strings.EqualFold(fields[0], "any"):
+		return NewAddrMask("0.0.0.0", "255.255.255.255")
+	case len(fields) == 2 && strings.EqualFold(fields[0], "host"):
+		ip := parseIP(fields[1])
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip: %v", fields[1])
+		}
+		return &AddrMask{ip: ip, wildcard: make(net.IPMask, len(ip))}, nil
+	case len(fields) == 2:
+		return NewAddrMask(fields[0], fields[1])
+	}
+	return nil, fmt.Errorf("invalid address mask: %v", s)
+}
+
+// String returns the ACL representation of the AddrMask, like "10.1.0.5 0.0.255.0"
+func (m AddrMask) String() string {
+	wc := net.IP(m.wildcard)
+	if len(wc) == net.IPv6len && wc.To4() != nil {
+		// an IPv6 wildcard within ::ffff:0:0/96 would be printed as IPv4
+		return fmt.Sprintf("%v ::ffff:%x:%x", m.ip, uint16(wc[12])<<8|uint16(wc[13]), uint16(wc[14])<<8|uint16(wc[15]))
+	}
+	return m.ip.String() + " " + wc.String()
+}
+
+// IP returns the address of the AddrMask, with all wildcard bits set to zero
+func (m AddrMask) IP() net.IP {
+	return m.ip
+}
+
+// Wildcard returns the wildcard mask of the AddrMask
+func (m AddrMask) Wildcard() net.IPMask {
+	return m.wildcard
+}
+
+// IsIPv4 reports whether the AddrMask is IPv4
+func (m AddrMask) IsIPv4() bool {
+	return len(m.ip) == net.IPv4len
+}
+
+// IsIPv6 reports whether the AddrMask is IPv6
+func (m AddrMask) IsIPv6() bool {
+	return len(m.ip) == net.IPv6len
+}
+
+// Match reports whether ip matches the AddrMask
+func (m AddrMask) Match(ip string) bool {
+	ipObj := parseIP(ip)
+	if ipObj == nil || len(ipObj) != len(m.ip) {
+		return false
+	}
+	for i := range ipObj {
+		if ipObj[i]&^m.wildcard[i] != m.ip[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// trailingOnes returns the number of contiguous wildcard bits counting from the lowest bit
+func (m AddrMask) trailingOnes() int {
+	bits := len(m.wildcard) * 8
+	n := 0
+	for n < bits && ipBit(m.wildcard, bits-n-1) == 1 {
+		n++
+	}
+	return n
+}
+
+// freeBits returns the positions of the wildcard bits which are not part of the trailing contiguous ones
+func (m AddrMask) freeBits() []int {
+	bits := len(m.wildcard)*8 - m.trailingOnes()
+	var pos []int
+	for i := 0; i < bits; i++ {
+		if ipBit(m.wildcard, i) == 1 {
+			pos = append(pos, i)
+		}
+	}
+	return pos
+}
+
+// IsContiguous reports whether the AddrMask is equivalent to a single CIDR
+func (m AddrMask) IsContiguous() bool {
+	return len(m.freeBits()) == 0
+}
+
+// IPCount returns the number of IPs matched by the AddrMask
+func (m AddrMask) IPCount() *big.Int {
+	n := 0
+	for i := 0; i < len(m.wildcard)*8; i++ {
+		n += int(ipBit(m.wildcard, i))
+	}
+	return big.NewInt(0).Lsh(bigIntOne, uint(n))
+}
+
+// eachCIDR iterates over the minimal set of CIDRs matched by the AddrMask in ascending order
+func (m AddrMask) eachCIDR(iterator func(c *CIDR) bool) {
+	ones := len(m.ip)*8 - m.trailingOnes()
+	free := m.freeBits()
+	ip := make(net.IP, len(m.ip))
+	copy(ip, m.ip)
+	for {
+		if !iterator(newCIDR(ip, ones)) {
+			return
+		}
+		// treat the free bits as a counter, the last free bit is the least significant
+		i := len(free) - 1
+		for ; i >= 0; i-- {
+			if ipBit(ip, free[i]) == 0 {
+				setIPBit(ip, free[i], 1)
+				break
+			}
+			setIPBit(ip, free[i], 0)
+		}
+		if i < 0 {
+			return
+		}
+	}
+}
+
+// CIDRs returns the minimal set of CIDRs matching exactly the same IPs as the AddrMask.
+// 	A contiguous AddrMask results in a single CIDR, otherwise every non-contiguous wildcard bit
+// doubles the number of CIDRs.
+func (m AddrMask) CIDRs() ([]*CIDR, error) {
+	free := m.freeBits()
+	if len(free) > 16 || 1<<uint(len(free)) > maxSubnetNum {
+		return nil, fmt.Errorf("cidr number exceeds maximum limit of %d", maxSubnetNum)
+	}
+	cidrArr := make([]*CIDR, 0, 1<<uint(len(free)))
+	m.eachCIDR(func(c *CIDR) bool {
+		cidrArr = append(cidrArr, c)
+		return true
+	})
+	return cidrArr, nil
+}
+
+// Each iterates over all IPs matched by the AddrMask
+func (m AddrMask) Each(iterator func(ip string) bool) {
+	m.eachCIDR(func(c *CIDR) bool {
+		next := true
+		c.Each(func(ip string) bool {
+			next = iterator(ip)
+			return next
+		})
+		return next
+	})
+}
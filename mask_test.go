@@ -0,0 +1,100 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseAddrMask(t *testing.T) {
+	m, err := ParseAddrMask("10.1.0.5 0.0.255.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.1.0.5 0.0.255.0", m.String())
+	assert.Equal(t, true, m.IsIPv4())
+
+	m, err = ParseAddrMask("10.1.7.5 0.0.255.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.1.0.5 0.0.255.0", m.String())
+
+	m, err = ParseAddrMask("host 10.1.0.5")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.1.0.5 0.0.0.0", m.String())
+
+	m, err = ParseAddrMask("any")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.0.0 255.255.255.255", m.String())
+
+	m, err = ParseAddrMask("2001:db8::1 0:0:ffff::")
+	assert.NoError(t, err)
+	assert.Equal(t, true, m.IsIPv6())
+
+	// an IPv6 wildcard within ::ffff:0:0/96 is not IPv4
+	m, err = NewAddrMask("2001:db8::", "::ffff:0:ff")
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, m.IsIPv6())
+		assert.Equal(t, true, m.Match("2001:db8::ffff:0:ff"))
+		assert.Equal(t, false, m.Match("2001:db8::1:0"))
+		assert.Equal(t, "2001:db8:: ::ffff:0:ff", m.String())
+	}
+	_, err = NewAddrMask("2001:db8::", "0.0.0.255")
+	assert.Error(t, err)
+
+	_, err = ParseAddrMask("10.1.0.5 ::ff")
+	assert.Error(t, err)
+	_, err = ParseAddrMask("10.1.0.5")
+	assert.Error(t, err)
+}
+
+func TestAddrMask_Match(t *testing.T) {
+	m, _ := ParseAddrMask("10.1.0.5 0.0.255.0")
+	assert.Equal(t, true, m.Match("10.1.0.5"))
+	assert.Equal(t, true, m.Match("10.1.200.5"))
+	assert.Equal(t, false, m.Match("10.1.200.6"))
+	assert.Equal(t, false, m.Match("10.2.0.5"))
+	assert.Equal(t, false, m.Match("::1"))
+	assert.Equal(t, false, m.Match("invalid"))
+
+	m, _ = ParseAddrMask("2001:db8::1 0:0:ffff::")
+	assert.Equal(t, true, m.Match("2001:db8:abcd::1"))
+	assert.Equal(t, false, m.Match("2001:db8:abcd::2"))
+}
+
+func TestAddrMask_CIDRs(t *testing.T) {
+	m, _ := ParseAddrMask("192.168.1.0 0.0.0.255")
+	assert.Equal(t, true, m.IsContiguous())
+	cs, err := m.CIDRs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(cs))
+	assert.Equal(t, "192.168.1.0/24", cs[0].String())
+
+	m, _ = ParseAddrMask("10.0.0.0 0.0.1.3")
+	assert.Equal(t, false, m.IsContiguous())
+	assert.Equal(t, int64(8), m.IPCount().Int64())
+	cs, err = m.CIDRs()
+	assert.NoError(t, err)
+	var arr []string
+	for _, c := range cs {
+		arr = append(arr, c.String())
+	}
+	assert.Equal(t, []string{"10.0.0.0/30", "10.0.1.0/30"}, arr)
+
+	m, _ = ParseAddrMask("10.0.0.0 0.255.255.254")
+	_, err = m.CIDRs()
+	assert.Error(t, err)
+}
+
+func TestAddrMask_Each(t *testing.T) {
+	m, _ := ParseAddrMask("10.0.0.0 0.0.1.1")
+	var arr []string
+	m.Each(func(ip string) bool {
+		arr = append(arr, ip)
+		return true
+	})
+	assert.Equal(t, []string{"10.0.0.0", "10.0.0.1", "10.0.1.0", "10.0.1.1"}, arr)
+
+	arr = arr[:0]
+	m.Each(func(ip string) bool {
+		arr = append(arr, ip)
+		return len(arr) < 3
+	})
+	assert.Equal(t, []string{"10.0.0.0", "10.0.0.1", "10.0.1.0"}, arr)
+}
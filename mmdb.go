@@ -0,0 +1,674 @@
+package cidr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// MaxMind DB file format, see https://maxmind.github.io/MaxMind-DB/
+
+const (
+	mmdbPointer   = 1
+	mmdbString    = 2
+	mmdbDouble    = 3
+	mmdbBytes     = 4
+	mmdbUint16    = 5
+	mmdbUint32    = 6
+	mmdbMap       = 7
+	mmdbInt32     = 8
+	mmdbUint64    = 9
+	mmdbUint128   = 10
+	mmdbArray     = 11
+	mmdbContainer = 12
+	mmdbEndMarker = 13
+	mmdbBool      = 14
+	mmdbFloat     = 15
+)
+
+const (
+	mmdbDataSeparatorSize = 16
+	mmdbMaxDecodeDepth    = 512
+	mmdbIPv4Depth         = 96 // IPv4 addresses are stored under ::/96 of an IPv6 tree
+)
+
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+func mmdbWriteControl(buf *bytes.Buffer, typ int, size int) {
+	var sizeBytes []byte
+	var first byte
+	switch {
+	case size < 29:
+		first = byte(size)
+	case size < 29+256:
+		first, sizeBytes = 29, []byte{byte(size - 29)}
+	case size < 285+65536:
+		s := size - 285
+		first, sizeBytes = 30, []byte{byte(s >> 8), byte(s)}
+	default:
+		s := size - 65821
+		first, sizeBytes = 31, []byte{byte(s >> 16), byte(s >> 8), byte(s)}
+	}
+	if typ <= mmdbMap {
+		buf.WriteByte(byte(typ<<5) | first)
+	} else {
+		buf.WriteByte(first)
+		buf.WriteByte(byte(typ - 7))
+	}
+	buf.Write(sizeBytes)
+}
+
+// mmdbTrimBytes returns b without leading zeros
+func mmdbTrimBytes(b []byte) []byte {
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+func mmdbWriteUint(buf *bytes.Buffer, typ int, n uint64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	b = mmdbTrimBytes(b)
+	mmdbWriteControl(buf, typ, len(b))
+	buf.Write(b)
+}
+
+// mmdbEncode encodes v to the MMDB data format.
+// 	Supported types are string, bool, float32, float64, []byte, signed integers (encoded as int32 if they fit,
+// uint64 otherwise), unsigned integers, *big.Int (uint128), maps with string keys, slices and arrays.
+func mmdbEncode(buf *bytes.Buffer, v interface{}) error {
+	switch x := v.(type) {
+	case string:
+		mmdbWriteControl(buf, mmdbString, len(x))
+		buf.WriteString(x)
+		return nil
+	case []byte:
+		mmdbWriteControl(buf, mmdbBytes, len(x))
+		buf.Write(x)
+		return nil
+	case bool:
+		size := 0
+		if x {
+			size = 1
+		}
+		mmdbWriteControl(buf, mmdbBool, size)
+		return nil
+	case float64:
+		mmdbWriteControl(buf, mmdbDouble, 8)
+		return binary.Write(buf, binary.BigEndian, x)
+	case float32:
+		mmdbWriteControl(buf, mmdbFloat, 4)
+		return binary.Write(buf, binary.BigEndian, x)
+	case *big.Int:
+		if x.Sign() < 0 || x.BitLen() > 128 {
+			return fmt.Errorf("uint128 out of range: %v", x)
+		}
+		b := x.Bytes()
+		mmdbWriteControl(buf, mmdbUint128, len(b))
+		buf.Write(b)
+		return nil
+	case nil:
+		return fmt.Errorf("unsupported nil value")
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := rv.Int()
+		if n >= math.MinInt32 && n <= math.MaxInt32 {
+			b := make([]byte, 4)
+			binary.BigEndian.PutUint32(b, uint32(int32(n)))
+			if n >= 0 {
+				b = mmdbTrimBytes(b)
+			}
+			mmdbWriteControl(buf, mmdbInt32, len(b))
+			buf.Write(b)
+			return nil
+		}
+		if n < 0 {
+			return fmt.Errorf("int out of int32 range: %v", n)
+		}
+		mmdbWriteUint(buf, mmdbUint64, uint64(n))
+		return nil
+	case reflect.Uint8, reflect.Uint16:
+		mmdbWriteUint(buf, mmdbUint16, rv.Uint())
+		return nil
+	case reflect.Uint32:
+		mmdbWriteUint(buf, mmdbUint32, rv.Uint())
+		return nil
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		mmdbWriteUint(buf, mmdbUint64, rv.Uint())
+		return nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type: %v", rv.Type().Key())
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		mmdbWriteControl(buf, mmdbMap, len(keys))
+		for _, k := range keys {
+			if err := mmdbEncode(buf, k.String()); err != nil {
+				return err
+			}
+			if err := mmdbEncode(buf, rv.MapIndex(k).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		mmdbWriteControl(buf, mmdbArray, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if err := mmdbEncode(buf, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported value type: %T", v)
+}
+
+// mmdbDecoder decodes values of a data section or the metadata section
+type mmdbDecoder struct {
+	buf []byte
+}
+
+func (d mmdbDecoder) read(offset, n int) ([]byte, error) {
+	if offset < 0 || n < 0 || offset+n > len(d.buf) {
+		return nil, fmt.Errorf("invalid database: unexpected end of data")
+	}
+	return d.buf[offset : offset+n], nil
+}
+
+func mmdbUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+// mmdbSizeHint returns the capacity to allocate for size elements, bounded by the data length against corrupted sizes
+func mmdbSizeHint(size int, buf []byte) int {
+	if size > len(buf) {
+		return len(buf)
+	}
+	return size
+}
+
+// decode returns the value at offset and the offset following it
+func (d mmdbDecoder) decode(offset, depth int) (interface{}, int, error) {
+	if depth > mmdbMaxDecodeDepth {
+		return nil, 0, fmt.Errorf("invalid database: exceeded maximum data structure depth")
+	}
+	b, err := d.read(offset, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctrl := b[0]
+	offset++
+	typ := int(ctrl >> 5)
+
+	if typ == mmdbPointer {
+		ss := int(ctrl>>3) & 0x3
+		n := ss + 1
+		if b, err = d.read(offset, n); err != nil {
+			return nil, 0, err
+		}
+		var p uint64
+		switch ss {
+		case 0:
+			p = uint64(ctrl&0x7)<<8 | mmdbUint(b)
+		case 1:
+			p = (uint64(ctrl&0x7)<<16 | mmdbUint(b)) + 2048
+		case 2:
+			p = (uint64(ctrl&0x7)<<24 | mmdbUint(b)) + 526336
+		default:
+			p = mmdbUint(b)
+		}
+		v, _, err := d.decode(int(p), depth+1)
+		return v, offset + n, err
+	}
+
+	if typ == 0 {
+		if b, err = d.read(offset, 1); err != nil {
+			return nil, 0, err
+		}
+		typ = 7 + int(b[0])
+		offset++
+	}
+
+	size := int(ctrl & 0x1F)
+	if size >= 29 {
+		n := size - 28
+		if b, err = d.read(offset, n); err != nil {
+			return nil, 0, err
+		}
+		offset += n
+		switch n {
+		case 1:
+			size = 29 + int(b[0])
+		case 2:
+			size = 285 + int(mmdbUint(b))
+		default:
+			size = 65821 + int(mmdbUint(b))
+		}
+	}
+
+	switch typ {
+	case mmdbBool:
+		return size != 0, offset, nil
+	case mmdbMap:
+		m := make(map[string]interface{}, mmdbSizeHint(size, d.buf))
+		for i := 0; i < size; i++ {
+			k, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("invalid database: map key is not a string")
+			}
+			if m[key], offset, err = d.decode(next, depth+1); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, offset, nil
+	case mmdbArray:
+		arr := make([]interface{}, 0, mmdbSizeHint(size, d.buf))
+		for i := 0; i < size; i++ {
+			v, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			arr = append(arr, v)
+			offset = next
+		}
+		return arr, offset, nil
+	}
+
+	if b, err = d.read(offset, size); err != nil {
+		return nil, 0, err
+	}
+	offset += size
+	switch typ {
+	case mmdbString:
+		return string(b), offset, nil
+	case mmdbBytes:
+		return append([]byte(nil), b...), offset, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid database: double size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid database: float size %d", size)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), offset, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("invalid database: uint size %d", size)
+		}
+		return mmdbUint(b), offset, nil
+	case mmdbInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("invalid database: int32 size %d", size)
+		}
+		return int(int32(uint32(mmdbUint(b)))), offset, nil
+	case mmdbUint128:
+		return big.NewInt(0).SetBytes(b), offset, nil
+	}
+	return nil, 0, fmt.Errorf("invalid database: unsupported data type %d", typ)
+}
+
+// MMDBWriter writes CIDRs with their values to a MaxMind DB file, an IPv6 database with IPv4 under ::/96.
+// 	When CIDRs overlap, the most specific one wins regardless of the insertion order.
+type MMDBWriter struct {
+	DatabaseType string
+	Description  map[string]string
+	Languages    []string
+	// BuildEpoch is the unix time of the database build, defaults to the time of writing
+	BuildEpoch int64
+
+	entries []mmdbEntry
+}
+
+type mmdbEntry struct {
+	ip   net.IP // 16 bytes
+	ones int    // prefix length in the IPv6 tree
+	data []byte
+}
+
+// NewMMDBWriter returns an MMDBWriter of the database type, like "My-IP-Metadata"
+func NewMMDBWriter(databaseType string) *MMDBWriter {
+	return &MMDBWriter{DatabaseType: databaseType}
+}
+
+// Insert adds c with value to the database, see MMDBReader.Lookup for the types after decoding.
+// 	Supported types are string, bool, float32, float64, []byte, integers, *big.Int (uint128),
+// maps with string keys, slices and arrays of them.
+func (w *MMDBWriter) Insert(c *CIDR, value interface{}) error {
+	var buf bytes.Buffer
+	if err := mmdbEncode(&buf, value); err != nil {
+		return err
+	}
+	ip, ones := trieKey(c)
+	if len(ip) == net.IPv4len {
+		ip, ones = append(make(net.IP, net.IPv6len-net.IPv4len), ip...), ones+mmdbIPv4Depth
+	}
+	w.entries = append(w.entries, mmdbEntry{ip: ip, ones: ones, data: buf.Bytes()})
+	return nil
+}
+
+type mmdbNode struct {
+	records [2]mmdbRecord
+	id      int
+}
+
+type mmdbRecord struct {
+	node *mmdbNode
+	data int // offset in the data section, -1 for no data
+}
+
+// WriteTo writes the database to out
+func (w *MMDBWriter) WriteTo(out io.Writer) (int64, error) {
+	// deduplicated data section
+	var data bytes.Buffer
+	offsets := make(map[string]int)
+	entryOffsets := make([]int, len(w.entries))
+	for i, e := range w.entries {
+		off, ok := offsets[string(e.data)]
+		if !ok {
+			off = data.Len()
+			offsets[string(e.data)] = off
+			data.Write(e.data)
+		}
+		entryOffsets[i] = off
+	}
+
+	// insert from the least specific, so a more specific CIDR splits the records it falls in
+	order := make([]int, len(w.entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return w.entries[order[i]].ones < w.entries[order[j]].ones
+	})
+	root := &mmdbNode{records: [2]mmdbRecord{{data: -1}, {data: -1}}}
+	for _, idx := range order {
+		e := w.entries[idx]
+		if e.ones == 0 {
+			root.records[0] = mmdbRecord{data: entryOffsets[idx]}
+			root.records[1] = mmdbRecord{data: entryOffsets[idx]}
+			continue
+		}
+		node := root
+		for i := 0; i < e.ones-1; i++ {
+			rec := &node.records[ipBit(e.ip, i)]
+			if rec.node == nil {
+				rec.node = &mmdbNode{records: [2]mmdbRecord{{data: rec.data}, {data: rec.data}}}
+				rec.data = -1
+			}
+			node = rec.node
+		}
+		node.records[ipBit(e.ip, e.ones-1)] = mmdbRecord{data: entryOffsets[idx]}
+	}
+
+	// number the nodes in breadth-first order
+	nodes := []*mmdbNode{root}
+	for i := 0; i < len(nodes); i++ {
+		nodes[i].id = i
+		for _, rec := range nodes[i].records {
+			if rec.node != nil {
+				nodes = append(nodes, rec.node)
+			}
+		}
+	}
+	nodeCount := len(nodes)
+	recordSize := 24
+	if maxValue := nodeCount + mmdbDataSeparatorSize + data.Len(); maxValue >= 1<<28 {
+		recordSize = 32
+	} else if maxValue >= 1<<24 {
+		recordSize = 28
+	}
+
+	var buf bytes.Buffer
+	nodeBytes := make([]byte, recordSize/4)
+	for _, n := range nodes {
+		var values [2]uint32
+		for i, rec := range n.records {
+			switch {
+			case rec.node != nil:
+				values[i] = uint32(rec.node.id)
+			case rec.data >= 0:
+				values[i] = uint32(nodeCount + mmdbDataSeparatorSize + rec.data)
+			default:
+				values[i] = uint32(nodeCount)
+			}
+		}
+		mmdbPutNode(nodeBytes, recordSize, values[0], values[1])
+		buf.Write(nodeBytes)
+	}
+	buf.Write(make([]byte, mmdbDataSeparatorSize))
+	buf.Write(data.Bytes())
+
+	buildEpoch := w.BuildEpoch
+	if buildEpoch == 0 {
+		buildEpoch = time.Now().Unix()
+	}
+	description := w.Description
+	if description == nil {
+		description = map[string]string{}
+	}
+	languages := w.Languages
+	if languages == nil {
+		languages = []string{}
+	}
+	buf.Write(mmdbMetadataMarker)
+	err := mmdbEncode(&buf, map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(buildEpoch),
+		"database_type":               w.DatabaseType,
+		"description":                 description,
+		"ip_version":                  uint16(6),
+		"languages":                   languages,
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(out)
+}
+
+// mmdbPutNode encodes a node of the search tree into b, which is recordSize/4 bytes
+func mmdbPutNode(b []byte, recordSize int, left, right uint32) {
+	switch recordSize {
+	case 24:
+		b[0], b[1], b[2] = byte(left>>16), byte(left>>8), byte(left)
+		b[3], b[4], b[5] = byte(right>>16), byte(right>>8), byte(right)
+	case 28:
+		b[0], b[1], b[2] = byte(left>>16), byte(left>>8), byte(left)
+		b[3] = byte(left>>24)<<4 | byte(right>>24)&0x0F
+		b[4], b[5], b[6] = byte(right>>16), byte(right>>8), byte(right)
+	default:
+		binary.BigEndian.PutUint32(b[0:4], left)
+		binary.BigEndian.PutUint32(b[4:8], right)
+	}
+}
+
+// MMDBMetadata is the metadata of a MaxMind DB file
+type MMDBMetadata struct {
+	NodeCount                uint
+	RecordSize               uint
+	IPVersion                uint
+	DatabaseType             string
+	Languages                []string
+	BinaryFormatMajorVersion uint
+	BinaryFormatMinorVersion uint
+	BuildEpoch               uint64
+	Description              map[string]string
+}
+
+// MMDBReader looks up IPs in a MaxMind DB file
+type MMDBReader struct {
+	Metadata MMDBMetadata
+
+	buf       []byte
+	data      mmdbDecoder
+	ipv4Start uint
+	ipv4Depth int
+}
+
+// OpenMMDB reads the MaxMind DB file of path into memory
+func OpenMMDB(path string) (*MMDBReader, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMMDBReader(buf)
+}
+
+// NewMMDBReader returns an MMDBReader of the MaxMind DB file content buf
+func NewMMDBReader(buf []byte) (*MMDBReader, error) {
+	idx := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if idx < 0 {
+		return nil, fmt.Errorf("invalid database: metadata not found")
+	}
+	v, _, err := mmdbDecoder{buf: buf[idx+len(mmdbMetadataMarker):]}.decode(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid database: metadata is not a map")
+	}
+
+	r := &MMDBReader{buf: buf}
+	meta := &r.Metadata
+	getUint := func(key string) uint {
+		n, _ := m[key].(uint64)
+		return uint(n)
+	}
+	meta.NodeCount = getUint("node_count")
+	meta.RecordSize = getUint("record_size")
+	meta.IPVersion = getUint("ip_version")
+	meta.BinaryFormatMajorVersion = getUint("binary_format_major_version")
+	meta.BinaryFormatMinorVersion = getUint("binary_format_minor_version")
+	meta.BuildEpoch, _ = m["build_epoch"].(uint64)
+	meta.DatabaseType, _ = m["database_type"].(string)
+	if arr, ok := m["languages"].([]interface{}); ok {
+		for _, lang := range arr {
+			if s, ok := lang.(string); ok {
+				meta.Languages = append(meta.Languages, s)
+			}
+		}
+	}
+	if desc, ok := m["description"].(map[string]interface{}); ok {
+		meta.Description = make(map[string]string, len(desc))
+		for k, v := range desc {
+			meta.Description[k], _ = v.(string)
+		}
+	}
+
+	if meta.BinaryFormatMajorVersion != 2 {
+		return nil, fmt.Errorf("unsupported binary format version: %d", meta.BinaryFormatMajorVersion)
+	}
+	if meta.RecordSize != 24 && meta.RecordSize != 28 && meta.RecordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size: %d", meta.RecordSize)
+	}
+	if meta.IPVersion != 4 && meta.IPVersion != 6 {
+		return nil, fmt.Errorf("unsupported ip version: %d", meta.IPVersion)
+	}
+	treeSize := int(meta.NodeCount * meta.RecordSize / 4)
+	if treeSize+mmdbDataSeparatorSize > idx {
+		return nil, fmt.Errorf("invalid database: search tree exceeds the file size")
+	}
+	r.data = mmdbDecoder{buf: buf[treeSize+mmdbDataSeparatorSize : idx]}
+
+	if meta.IPVersion == 6 {
+		for r.ipv4Depth < mmdbIPv4Depth && r.ipv4Start < meta.NodeCount {
+			r.ipv4Start = r.readRecord(r.ipv4Start, 0)
+			r.ipv4Depth++
+		}
+	}
+	return r, nil
+}
+
+// readRecord returns the record of node selected by bit
+func (r *MMDBReader) readRecord(node uint, bit byte) uint {
+	switch r.Metadata.RecordSize {
+	case 24:
+		off := node*6 + uint(bit)*3
+		return uint(r.buf[off])<<16 | uint(r.buf[off+1])<<8 | uint(r.buf[off+2])
+	case 28:
+		off := node * 7
+		if bit == 0 {
+			return uint(r.buf[off+3]&0xF0)<<20 | uint(r.buf[off])<<16 | uint(r.buf[off+1])<<8 | uint(r.buf[off+2])
+		}
+		return uint(r.buf[off+3]&0x0F)<<24 | uint(r.buf[off+4])<<16 | uint(r.buf[off+5])<<8 | uint(r.buf[off+6])
+	default:
+		off := node*8 + uint(bit)*4
+		return uint(binary.BigEndian.Uint32(r.buf[off : off+4]))
+	}
+}
+
+// Lookup returns the network of the search tree record including ip, and its decoded record.
+// 	The network is the inserted CIDR, or a part of it if more specific CIDRs were inserted inside it.
+// If ip is not in the database, the record is nil and the network is the one without data.
+// Records are decoded as map[string]interface{}, []interface{}, string, []byte, bool, float64, float32,
+// int (int32), uint64 (uint16, uint32 and uint64) and *big.Int (uint128).
+func (r *MMDBReader) Lookup(ip string) (*CIDR, interface{}, error) {
+	ipObj := parseIP(ip)
+	if ipObj == nil {
+		return nil, nil, fmt.Errorf("invalid ip: %v", ip)
+	}
+
+	node, depth := uint(0), 0
+	isV4InV6 := len(ipObj) == net.IPv4len && r.Metadata.IPVersion == 6
+	if len(ipObj) == net.IPv6len && r.Metadata.IPVersion == 4 {
+		return nil, nil, fmt.Errorf("can not look up ipv6 in an ipv4 database")
+	}
+	if isV4InV6 {
+		node, depth = r.ipv4Start, r.ipv4Depth
+	}
+
+	bits := len(ipObj) * 8
+	for i := 0; node < r.Metadata.NodeCount && i < bits; i++ {
+		node = r.readRecord(node, ipBit(ipObj, i))
+		depth++
+	}
+	if node < r.Metadata.NodeCount {
+		return nil, nil, fmt.Errorf("invalid database: search tree is too deep")
+	}
+
+	ones := depth
+	if isV4InV6 {
+		if ones -= mmdbIPv4Depth; ones < 0 {
+			ones = 0
+		}
+	}
+	c := newCIDR(ipObj, ones)
+	if node == r.Metadata.NodeCount {
+		return c, nil, nil
+	}
+
+	offset := int(node) - int(r.Metadata.NodeCount) - mmdbDataSeparatorSize
+	v, _, err := r.data.decode(offset, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, v, nil
+}
@@ -0,0 +1,161 @@
+package cidr
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMMDB(t *testing.T) {
+	w := NewMMDBWriter("Test-IP-Metadata")
+	w.Description = map[string]string{"en": "test database"}
+	w.Languages = []string{"en"}
+	w.BuildEpoch = 1700000000
+
+	assert.NoError(t, w.Insert(ParseNoError("10.1.2.0/24"), map[string]interface{}{"site": "lab", "vlan": 12}))
+	assert.NoError(t, w.Insert(ParseNoError("10.0.0.0/8"), map[string]interface{}{
+		"site":    "corp",
+		"tags":    []string{"internal", "rfc1918"},
+		"asn":     uint32(64512),
+		"weight":  0.5,
+		"offset":  -3,
+		"managed": true,
+	}))
+	assert.NoError(t, w.Insert(ParseNoError("2001:db8::/32"), "documentation"))
+	assert.NoError(t, w.Insert(ParseNoError("2001:db8:1::/48"), big.NewInt(0).Lsh(bigIntOne, 100)))
+	assert.NoError(t, w.Insert(ParseNoError("192.168.0.0/16"), map[string]interface{}{"site": "lab", "vlan": 12}))
+	assert.Error(t, w.Insert(ParseNoError("172.16.0.0/12"), nil))
+	assert.Error(t, w.Insert(ParseNoError("172.16.0.0/12"), map[int]string{1: "a"}))
+	// IPv4-mapped is inserted as IPv4
+	assert.NoError(t, w.Insert(ParseNoError("::ffff:172.16.0.0/108"), "mapped"))
+
+	var buf bytes.Buffer
+	_, err := w.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	r, err := NewMMDBReader(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "Test-IP-Metadata", r.Metadata.DatabaseType)
+	assert.Equal(t, uint(6), r.Metadata.IPVersion)
+	assert.Equal(t, uint(24), r.Metadata.RecordSize)
+	assert.Equal(t, uint(2), r.Metadata.BinaryFormatMajorVersion)
+	assert.Equal(t, uint64(1700000000), r.Metadata.BuildEpoch)
+	assert.Equal(t, []string{"en"}, r.Metadata.Languages)
+	assert.Equal(t, map[string]string{"en": "test database"}, r.Metadata.Description)
+
+	c, v, err := r.Lookup("10.1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.1.2.0/24", c.String())
+	assert.Equal(t, map[string]interface{}{"site": "lab", "vlan": 12}, v)
+
+	c, v, err = r.Lookup("10.200.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.128.0.0/9", c.String())
+	assert.Equal(t, map[string]interface{}{
+		"site":    "corp",
+		"tags":    []interface{}{"internal", "rfc1918"},
+		"asn":     uint64(64512),
+		"weight":  0.5,
+		"offset":  -3,
+		"managed": true,
+	}, v)
+
+	c, v, err = r.Lookup("2001:db8::1")
+	assert.NoError(t, err)
+	// the search tree splits 2001:db8::/32 around 2001:db8:1::/48
+	assert.Equal(t, "2001:db8::/48", c.String())
+	assert.Equal(t, "documentation", v)
+
+	_, v, _ = r.Lookup("2001:db8:1::1")
+	assert.Equal(t, big.NewInt(0).Lsh(bigIntOne, 100), v)
+
+	c, v, err = r.Lookup("11.0.0.1")
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	assert.Equal(t, "11.0.0.0/8", c.String())
+
+	_, v, _ = r.Lookup("192.168.200.1")
+	assert.Equal(t, map[string]interface{}{"site": "lab", "vlan": 12}, v)
+
+	c, v, err = r.Lookup("172.16.5.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "172.16.0.0/12", c.String())
+	assert.Equal(t, "mapped", v)
+
+	_, _, err = r.Lookup("invalid")
+	assert.Error(t, err)
+}
+
+func TestMMDB_OpenFile(t *testing.T) {
+	w := NewMMDBWriter("Test")
+	assert.NoError(t, w.Insert(ParseNoError("0.0.0.0/0"), "any4"))
+	assert.NoError(t, w.Insert(ParseNoError("192.0.2.0/24"), "doc"))
+
+	dir, err := ioutil.TempDir("", "mmdb")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.mmdb")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	_, err = w.WriteTo(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	r, err := OpenMMDB(path)
+	assert.NoError(t, err)
+	c, v, _ := r.Lookup("198.51.100.1")
+	assert.Equal(t, "196.0.0.0/6", c.String())
+	assert.Equal(t, "any4", v)
+	c, v, _ = r.Lookup("192.0.2.1")
+	assert.Equal(t, "192.0.2.0/24", c.String())
+	assert.Equal(t, "doc", v)
+	_, v, _ = r.Lookup("2001:db8::1")
+	assert.Nil(t, v)
+}
+
+func TestMMDB_Encoding(t *testing.T) {
+	// examples of the MaxMind DB file format spec
+	var buf bytes.Buffer
+	assert.NoError(t, mmdbEncode(&buf, "$"))
+	assert.Equal(t, []byte{0x41, 0x24}, buf.Bytes())
+
+	long := string(bytes.Repeat([]byte("a"), 500))
+	buf.Reset()
+	assert.NoError(t, mmdbEncode(&buf, long))
+	assert.Equal(t, []byte{0x5E, 0x00, 0xD7}, buf.Bytes()[:3])
+	v, next, err := mmdbDecoder{buf: buf.Bytes()}.decode(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, long, v)
+	assert.Equal(t, buf.Len(), next)
+
+	buf.Reset()
+	assert.NoError(t, mmdbEncode(&buf, true))
+	assert.Equal(t, []byte{0x01, 0x07}, buf.Bytes())
+
+	// pointers
+	data := []byte{0x41, 0x24, 0x20, 0x00}
+	v, next, err = mmdbDecoder{buf: data}.decode(2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "$", v)
+	assert.Equal(t, 4, next)
+
+	_, _, err = mmdbDecoder{buf: []byte{0x5E, 0x00}}.decode(0, 0)
+	assert.Error(t, err)
+	_, _, err = mmdbDecoder{buf: []byte{0x20, 0x00}}.decode(0, 0)
+	assert.Error(t, err)
+
+	for _, size := range []int{24, 28, 32} {
+		r := &MMDBReader{buf: make([]byte, size/4*2)}
+		r.Metadata.RecordSize = uint(size)
+		mmdbPutNode(r.buf[size/4:], size, 0xFABCDEF&(1<<uint(size)-1), 0x9123456&(1<<uint(size)-1))
+		assert.Equal(t, uint(0xFABCDEF&(1<<uint(size)-1)), r.readRecord(1, 0), size)
+		assert.Equal(t, uint(0x9123456&(1<<uint(size)-1)), r.readRecord(1, 1), size)
+	}
+
+	_, err = NewMMDBReader([]byte("not a database"))
+	assert.Error(t, err)
+}
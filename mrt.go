@@ -0,0 +1,261 @@
+package cidr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// MRT TABLE_DUMP_V2 format, see RFC 6396 and RFC 8050
+const (
+	mrtHeaderSize      = 12
+	mrtMaxRecordSize   = 1 << 24
+	mrtTypeTableDumpV2 = 13
+
+	mrtPeerIndexTable          = 1
+	mrtRIBIPv4Unicast          = 2
+	mrtRIBIPv4Multicast        = 3
+	mrtRIBIPv6Unicast          = 4
+	mrtRIBIPv6Multicast        = 5
+	mrtRIBIPv4UnicastAddPath   = 8
+	mrtRIBIPv4MulticastAddPath = 9
+	mrtRIBIPv6UnicastAddPath   = 10
+	mrtRIBIPv6MulticastAddPath = 11
+
+	bgpAttrASPath      = 2
+	bgpAttrNextHop     = 3
+	bgpAttrMPReachNLRI = 14
+	bgpASSet           = 1
+	bgpASSequence      = 2
+)
+
+// MRTPeer is a BGP peer of a route collector
+type MRTPeer struct {
+	BGPID net.IP
+	IP    net.IP
+	ASN   uint32
+}
+
+// MRTRoute is the route of a prefix received from a peer
+type MRTRoute struct {
+	Prefix     *CIDR
+	Peer       MRTPeer
+	Originated time.Time
+	// PathID is the path identifier of ADD-PATH RIB entries, otherwise 0
+	PathID uint32
+	// ASPath is the AS_PATH with AS_SET members flattened in place
+	ASPath []uint32
+	// OriginAS is the last AS of the AS_PATH, 0 if the AS_PATH is empty or ends with an AS_SET
+	OriginAS uint32
+	NextHop  net.IP
+}
+
+// mrtBuffer reads fields of an MRT record, any read out of range sets err
+type mrtBuffer struct {
+	b   []byte
+	err error
+}
+
+func (m *mrtBuffer) next(n int) []byte {
+	if m.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(m.b) {
+		m.err = fmt.Errorf("unexpected end of record")
+		return nil
+	}
+	b := m.b[:n]
+	m.b = m.b[n:]
+	return b
+}
+
+func (m *mrtBuffer) uint8() uint8 {
+	if b := m.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (m *mrtBuffer) uint16() uint16 {
+	if b := m.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (m *mrtBuffer) uint32() uint32 {
+	if b := m.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (m *mrtBuffer) ip(size int) net.IP {
+	if b := m.next(size); b != nil {
+		return append(net.IP(nil), b...)
+	}
+	return nil
+}
+
+// EachMRTRoute iterates over the routes of an MRT TABLE_DUMP_V2 file (RFC 6396), like a RIB dump of
+// RIPE RIS or RouteViews, reading one record at a time from r.
+// 	Records of other types and RIB_GENERIC records are skipped. A gzip or bzip2 compressed file must be
+// decompressed by the caller, like EachMRTRoute(gzipReader, ...).
+func EachMRTRoute(r io.Reader, iterator func(route *MRTRoute) bool) error {
+	var peers []MRTPeer
+	header := make([]byte, mrtHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		typ := binary.BigEndian.Uint16(header[4:6])
+		subtype := binary.BigEndian.Uint16(header[6:8])
+		length := binary.BigEndian.Uint32(header[8:12])
+		if length > mrtMaxRecordSize {
+			return fmt.Errorf("mrt record size %d exceeds maximum limit of %d", length, mrtMaxRecordSize)
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return err
+		}
+		if typ != mrtTypeTableDumpV2 {
+			continue
+		}
+
+		buf := &mrtBuffer{b: body}
+		switch subtype {
+		case mrtPeerIndexTable:
+			peers = parseMRTPeerIndexTable(buf)
+		case mrtRIBIPv4Unicast, mrtRIBIPv4Multicast, mrtRIBIPv4UnicastAddPath, mrtRIBIPv4MulticastAddPath:
+			if !parseMRTRIB(buf, peers, net.IPv4len, subtype >= mrtRIBIPv4UnicastAddPath, iterator) {
+				return buf.err
+			}
+		case mrtRIBIPv6Unicast, mrtRIBIPv6Multicast, mrtRIBIPv6UnicastAddPath, mrtRIBIPv6MulticastAddPath:
+			if !parseMRTRIB(buf, peers, net.IPv6len, subtype >= mrtRIBIPv4UnicastAddPath, iterator) {
+				return buf.err
+			}
+		}
+		if buf.err != nil {
+			return fmt.Errorf("invalid mrt record of subtype %d: %v", subtype, buf.err)
+		}
+	}
+}
+
+func parseMRTPeerIndexTable(buf *mrtBuffer) []MRTPeer {
+	buf.next(4) // collector BGP ID
+	buf.next(int(buf.uint16()))
+	n := int(buf.uint16())
+	var peers []MRTPeer
+	for i := 0; i < n && buf.err == nil; i++ {
+		peerType := buf.uint8()
+		peer := MRTPeer{BGPID: buf.ip(net.IPv4len)}
+		if peerType&0x01 != 0 {
+			peer.IP = buf.ip(net.IPv6len)
+		} else {
+			peer.IP = buf.ip(net.IPv4len)
+		}
+		if peerType&0x02 != 0 {
+			peer.ASN = buf.uint32()
+		} else {
+			peer.ASN = uint32(buf.uint16())
+		}
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+// parseMRTRIB parses a RIB record and reports whether the iteration should continue
+func parseMRTRIB(buf *mrtBuffer, peers []MRTPeer, ipLen int, addPath bool, iterator func(route *MRTRoute) bool) bool {
+	buf.next(4) // sequence number
+	ones := int(buf.uint8())
+	if ones > ipLen*8 {
+		buf.err = fmt.Errorf("invalid prefix length %d", ones)
+		return true
+	}
+	ip := make(net.IP, ipLen)
+	copy(ip, buf.next((ones+7)/8))
+	prefix := newCIDR(ip, ones)
+
+	n := int(buf.uint16())
+	for i := 0; i < n && buf.err == nil; i++ {
+		peerIndex := int(buf.uint16())
+		route := &MRTRoute{Prefix: prefix, Originated: time.Unix(int64(buf.uint32()), 0).UTC()}
+		if addPath {
+			route.PathID = buf.uint32()
+		}
+		attrs := &mrtBuffer{b: buf.next(int(buf.uint16()))}
+		if buf.err != nil {
+			return true
+		}
+		if peerIndex >= len(peers) {
+			buf.err = fmt.Errorf("peer index %d out of range", peerIndex)
+			return true
+		}
+		route.Peer = peers[peerIndex]
+		if parseBGPAttributes(attrs, route); attrs.err != nil {
+			buf.err = fmt.Errorf("invalid bgp attributes: %v", attrs.err)
+			return true
+		}
+		if !iterator(route) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseBGPAttributes(buf *mrtBuffer, route *MRTRoute) {
+	for len(buf.b) > 0 && buf.err == nil {
+		flags := buf.uint8()
+		typ := buf.uint8()
+		var n int
+		if flags&0x10 != 0 {
+			n = int(buf.uint16())
+		} else {
+			n = int(buf.uint8())
+		}
+		attr := &mrtBuffer{b: buf.next(n)}
+		if buf.err != nil {
+			return
+		}
+
+		switch typ {
+		case bgpAttrASPath:
+			// TABLE_DUMP_V2 always encodes 4-byte AS numbers
+			var last uint8
+			for len(attr.b) > 0 && attr.err == nil {
+				last = attr.uint8()
+				count := int(attr.uint8())
+				for i := 0; i < count && attr.err == nil; i++ {
+					route.ASPath = append(route.ASPath, attr.uint32())
+				}
+			}
+			route.OriginAS = 0
+			if last == bgpASSequence && len(route.ASPath) > 0 {
+				route.OriginAS = route.ASPath[len(route.ASPath)-1]
+			}
+		case bgpAttrNextHop:
+			route.NextHop = attr.ip(net.IPv4len)
+		case bgpAttrMPReachNLRI:
+			// RFC 6396 abbreviates the attribute to the next hop length and address,
+			// but some implementations keep the AFI and SAFI as well
+			if n > 3 && int(attr.b[0])+1 != n {
+				attr.next(3)
+			}
+			nextHop := attr.next(int(attr.uint8()))
+			if len(nextHop) >= net.IPv6len {
+				// the global address, followed by the link-local address if 32 bytes
+				route.NextHop = append(net.IP(nil), nextHop[:net.IPv6len]...)
+			} else if len(nextHop) == net.IPv4len {
+				route.NextHop = append(net.IP(nil), nextHop...)
+			}
+		}
+		if attr.err != nil {
+			buf.err = attr.err
+		}
+	}
+}
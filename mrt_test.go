@@ -0,0 +1,139 @@
+package cidr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func mrtRecord(subtype uint16, body []byte) []byte {
+	b := make([]byte, mrtHeaderSize)
+	binary.BigEndian.PutUint32(b[0:4], 1700000000)
+	binary.BigEndian.PutUint16(b[4:6], mrtTypeTableDumpV2)
+	binary.BigEndian.PutUint16(b[6:8], subtype)
+	binary.BigEndian.PutUint32(b[8:12], uint32(len(body)))
+	return append(b, body...)
+}
+
+func mrtRIBEntry(peerIndex uint16, attrs []byte) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint16(b[0:2], peerIndex)
+	binary.BigEndian.PutUint32(b[2:6], 1690000000)
+	binary.BigEndian.PutUint16(b[6:8], uint16(len(attrs)))
+	return append(b, attrs...)
+}
+
+func mrtASPath(segments ...[]uint32) []byte {
+	var seg []byte
+	for i, asns := range segments {
+		typ := byte(bgpASSequence)
+		if i%2 == 1 {
+			typ = bgpASSet
+		}
+		seg = append(seg, typ, byte(len(asns)))
+		for _, asn := range asns {
+			seg = append(seg, byte(asn>>24), byte(asn>>16), byte(asn>>8), byte(asn))
+		}
+	}
+	return append([]byte{0x40, bgpAttrASPath, byte(len(seg))}, seg...)
+}
+
+func buildMRTDump() []byte {
+	var buf bytes.Buffer
+
+	// peer index table: an IPv4 peer with 2-byte AS and an IPv6 peer with 4-byte AS
+	peers := []byte{192, 0, 2, 254, 0, 4, 't', 'e', 's', 't', 0, 2}
+	peers = append(peers, 0x00, 10, 0, 0, 1, 198, 51, 100, 1, 0xFB, 0xF0)
+	peers = append(peers, 0x03, 10, 0, 0, 2)
+	peers = append(peers, net.ParseIP("2001:db8::1")...)
+	peers = append(peers, 0, 0x01, 0x00, 0x00)
+	buf.Write(mrtRecord(mrtPeerIndexTable, peers))
+
+	// a record of another type is skipped
+	other := mrtRecord(0, []byte{1, 2, 3})
+	binary.BigEndian.PutUint16(other[4:6], 16)
+	buf.Write(other)
+
+	// 192.0.2.0/24 from both peers
+	rib := []byte{0, 0, 0, 1, 24, 192, 0, 2, 0, 2}
+	attrs := append([]byte{0x40, 1, 1, 0}, mrtASPath([]uint32{64496, 64497})...)
+	attrs = append(attrs, 0x40, bgpAttrNextHop, 4, 198, 51, 100, 1)
+	rib = append(rib, mrtRIBEntry(0, attrs)...)
+	attrs = mrtASPath([]uint32{65536}, []uint32{64498, 64499})
+	rib = append(rib, mrtRIBEntry(1, attrs)...)
+	buf.Write(mrtRecord(mrtRIBIPv4Unicast, rib))
+
+	// 2001:db8::/32 with an abbreviated MP_REACH_NLRI
+	rib = []byte{0, 0, 0, 2, 32, 0x20, 0x01, 0x0d, 0xb8, 0, 1}
+	attrs = mrtASPath([]uint32{65536, 64500})
+	attrs = append(attrs, 0x80, bgpAttrMPReachNLRI, 17, 16)
+	attrs = append(attrs, net.ParseIP("2001:db8::1")...)
+	rib = append(rib, mrtRIBEntry(1, attrs)...)
+	buf.Write(mrtRecord(mrtRIBIPv6Unicast, rib))
+
+	return buf.Bytes()
+}
+
+func TestEachMRTRoute(t *testing.T) {
+	var routes []*MRTRoute
+	err := EachMRTRoute(bytes.NewReader(buildMRTDump()), func(route *MRTRoute) bool {
+		routes = append(routes, route)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(routes))
+
+	assert.Equal(t, "192.0.2.0/24", routes[0].Prefix.String())
+	assert.Equal(t, "198.51.100.1", routes[0].Peer.IP.String())
+	assert.Equal(t, uint32(64496), routes[0].Peer.ASN)
+	assert.Equal(t, []uint32{64496, 64497}, routes[0].ASPath)
+	assert.Equal(t, uint32(64497), routes[0].OriginAS)
+	assert.Equal(t, "198.51.100.1", routes[0].NextHop.String())
+	assert.Equal(t, int64(1690000000), routes[0].Originated.Unix())
+
+	assert.Equal(t, "2001:db8::1", routes[1].Peer.IP.String())
+	assert.Equal(t, uint32(65536), routes[1].Peer.ASN)
+	assert.Equal(t, []uint32{65536, 64498, 64499}, routes[1].ASPath)
+	assert.Equal(t, uint32(0), routes[1].OriginAS)
+
+	assert.Equal(t, "2001:db8::/32", routes[2].Prefix.String())
+	assert.Equal(t, uint32(64500), routes[2].OriginAS)
+	assert.Equal(t, "2001:db8::1", routes[2].NextHop.String())
+
+	// build an origin map with the trie
+	trie := NewTrie()
+	_ = EachMRTRoute(bytes.NewReader(buildMRTDump()), func(route *MRTRoute) bool {
+		if route.OriginAS != 0 {
+			trie.Insert(route.Prefix, route.OriginAS)
+		}
+		return true
+	})
+	_, asn, _ := trie.Lookup("2001:db8::1234")
+	assert.Equal(t, uint32(64500), asn)
+
+	n := 0
+	err = EachMRTRoute(bytes.NewReader(buildMRTDump()), func(route *MRTRoute) bool {
+		n++
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestEachMRTRoute_Error(t *testing.T) {
+	dump := buildMRTDump()
+	iterator := func(route *MRTRoute) bool { return true }
+
+	// truncated
+	assert.Error(t, EachMRTRoute(bytes.NewReader(dump[:len(dump)-1]), iterator))
+
+	// RIB before the peer index table
+	rib := mrtRecord(mrtRIBIPv4Unicast, []byte{0, 0, 0, 1, 8, 10, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	assert.Error(t, EachMRTRoute(bytes.NewReader(rib), iterator))
+
+	// invalid prefix length
+	rib = mrtRecord(mrtRIBIPv4Unicast, []byte{0, 0, 0, 1, 33, 10, 0, 0, 0, 0, 0, 0})
+	assert.Error(t, EachMRTRoute(bytes.NewReader(rib), iterator))
+}
@@ -0,0 +1,244 @@
+package cidr
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// NodeAllocatorState is the serializable state of a NodeAllocator
+type NodeAllocatorState struct {
+	ClusterCIDR  string `json:"clusterCIDR"`
+	NodeMaskSize int    `json:"nodeMaskSize"`
+	// Bitmap is the hexadecimal bitmap of the allocated blocks, bit i for the i-th block
+	Bitmap string `json:"bitmap"`
+	// Next is the index of the block the next allocation starts searching from
+	Next int `json:"next"`
+}
+
+// NodeAllocator carves a cluster CIDR into fixed-size blocks allocated to nodes, like the pod CIDRs of
+// the nodes of a Kubernetes cluster, and keeps track of them in a bitmap.
+// 	NodeAllocator is safe for concurrent use.
+type NodeAllocator struct {
+	cluster   *CIDR
+	nodeOnes  int
+	hostBits  uint
+	blocks    int
+	clusterIP *big.Int
+
+	mu     sync.Mutex
+	bitmap *big.Int
+	used   int
+	next   int
+}
+
+// NewNodeAllocator returns a NodeAllocator of blocks of nodeOnes in cluster, like a /24 per node in 10.244.0.0/16.
+// 	The number of blocks must not exceed 65536. An IPv4-mapped cluster, like "::ffff:10.244.0.0/112" with /120 blocks,
+// is allocated as IPv4.
+func NewNodeAllocator(cluster *CIDR, nodeOnes int) (*NodeAllocator, error) {
+	ip, ones := trieKey(cluster)
+	bits := len(ip) * 8
+	// the mask lengths of an IPv4-mapped cluster are offset by 96
+	_, clusterBits := cluster.ipNet.Mask.Size()
+	offset := clusterBits - bits
+	nodeOnes -= offset
+	if nodeOnes < ones || nodeOnes > bits {
+		return nil, fmt.Errorf("node mask size %d must be between %d and %d", nodeOnes+offset, ones+offset, clusterBits)
+	}
+	if nodeOnes-ones > 16 {
+		return nil, fmt.Errorf("the number of blocks exceeds maximum limit of %d", maxSubnetNum)
+	}
+	return &NodeAllocator{
+		cluster:   newCIDR(ip, ones),
+		nodeOnes:  nodeOnes,
+		hostBits:  uint(bits - nodeOnes),
+		blocks:    1 << uint(nodeOnes-ones),
+		clusterIP: ipToInt(ip),
+		bitmap:    big.NewInt(0),
+	}, nil
+}
+
+// NewNodeAllocatorFromState returns the NodeAllocator of a state, see State
+func NewNodeAllocatorFromState(state NodeAllocatorState) (*NodeAllocator, error) {
+	cluster, err := Parse(state.ClusterCIDR)
+	if err != nil {
+		return nil, err
+	}
+	a, err := NewNodeAllocator(cluster, state.NodeMaskSize)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := a.bitmap.SetString(state.Bitmap, 16); !ok && state.Bitmap != "" {
+		return nil, fmt.Errorf("invalid bitmap: %v", state.Bitmap)
+	}
+	if a.bitmap.Sign() < 0 || a.bitmap.BitLen() > a.blocks {
+		return nil, fmt.Errorf("bitmap exceeds %d blocks", a.blocks)
+	}
+	if state.Next < 0 || state.Next >= a.blocks {
+		return nil, fmt.Errorf("next block %d out of range", state.Next)
+	}
+	a.next = state.Next
+	for i := 0; i < a.blocks; i++ {
+		a.used += int(a.bitmap.Bit(i))
+	}
+	return a, nil
+}
+
+// State returns the state of the allocator, which can be saved as JSON and restored by NewNodeAllocatorFromState
+func (a *NodeAllocator) State() NodeAllocatorState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return NodeAllocatorState{
+		ClusterCIDR:  a.cluster.String(),
+		NodeMaskSize: a.nodeOnes,
+		Bitmap:       a.bitmap.Text(16),
+		Next:         a.next,
+	}
+}
+
+// ClusterCIDR returns the cluster CIDR
+func (a *NodeAllocator) ClusterCIDR() *CIDR {
+	return a.cluster
+}
+
+// Blocks returns the number of blocks
+func (a *NodeAllocator) Blocks() int {
+	return a.blocks
+}
+
+// Used returns the number of allocated or occupied blocks
+func (a *NodeAllocator) Used() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.used
+}
+
+// Free returns the number of free blocks
+func (a *NodeAllocator) Free() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.blocks - a.used
+}
+
+// block returns the i-th block
+func (a *NodeAllocator) block(i int) *CIDR {
+	n := big.NewInt(int64(i))
+	n.Lsh(n, a.hostBits).Add(n, a.clusterIP)
+	ip, _ := trieKey(a.cluster)
+	return newCIDR(intToIP(n, len(ip)), a.nodeOnes)
+}
+
+// blockRange returns the indexes of the first and the last blocks overlapping c, false if c does not overlap
+// the cluster CIDR
+func (a *NodeAllocator) blockRange(c *CIDR) (int, int, bool) {
+	common := intersectRanges([]Range{cidrRange(a.cluster)}, []Range{cidrRange(c)})
+	if len(common) == 0 {
+		return 0, 0, false
+	}
+	index := func(ip []byte) int {
+		n := ipToInt(ip)
+		n.Sub(n, a.clusterIP).Rsh(n, a.hostBits)
+		return int(n.Int64())
+	}
+	return index(common[0].start), index(common[0].end), true
+}
+
+// Allocate allocates the next free block, searching from the block after the last allocated one
+func (a *NodeAllocator) Allocate() (*CIDR, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.used == a.blocks {
+		return nil, fmt.Errorf("cidr range %v exhausted", a.cluster)
+	}
+	for j := 0; j < a.blocks; j++ {
+		i := (a.next + j) % a.blocks
+		if a.bitmap.Bit(i) == 0 {
+			a.bitmap.SetBit(a.bitmap, i, 1)
+			a.used++
+			a.next = (i + 1) % a.blocks
+			return a.block(i), nil
+		}
+	}
+	return nil, fmt.Errorf("cidr range %v exhausted", a.cluster)
+}
+
+// Occupy marks the blocks overlapping c as allocated, like the blocks of a node restored from the cluster state,
+// or those overlapping the service CIDR.
+// 	c can be smaller or larger than a block, an error is returned if it does not overlap the cluster CIDR.
+func (a *NodeAllocator) Occupy(c *CIDR) error {
+	return a.setRange(c, 1)
+}
+
+// Release marks the blocks in c as free, c must be a block or cover whole blocks,
+// as releasing a part of a block would free the rest of it
+func (a *NodeAllocator) Release(c *CIDR) error {
+	if _, ones := trieKey(c); ones > a.nodeOnes && a.Overlapping([]*CIDR{c}) != nil {
+		return fmt.Errorf("%v is smaller than the /%d blocks", c, a.nodeOnes)
+	}
+	return a.setRange(c, 0)
+}
+
+func (a *NodeAllocator) setRange(c *CIDR, bit uint) error {
+	first, last, ok := a.blockRange(c)
+	if !ok {
+		return fmt.Errorf("%v does not overlap cidr range %v", c, a.cluster)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := first; i <= last; i++ {
+		if a.bitmap.Bit(i) != bit {
+			a.bitmap.SetBit(a.bitmap, i, bit)
+			if bit == 1 {
+				a.used++
+			} else {
+				a.used--
+			}
+		}
+	}
+	return nil
+}
+
+// IsAllocated reports whether all the blocks overlapping c are allocated, false if c does not overlap
+// the cluster CIDR
+func (a *NodeAllocator) IsAllocated(c *CIDR) bool {
+	first, last, ok := a.blockRange(c)
+	if !ok {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := first; i <= last; i++ {
+		if a.bitmap.Bit(i) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Allocated returns the allocated blocks in ascending order
+func (a *NodeAllocator) Allocated() []*CIDR {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var arr []*CIDR
+	for i := 0; i < a.blocks; i++ {
+		if a.bitmap.Bit(i) == 1 {
+			arr = append(arr, a.block(i))
+		}
+	}
+	return arr
+}
+
+// Overlapping returns the CIDRs of cs overlapping the cluster CIDR, like the service CIDR or the existing
+// host routes, which are usually occupied before allocating blocks to nodes
+func (a *NodeAllocator) Overlapping(cs []*CIDR) []*CIDR {
+	var arr []*CIDR
+	r := cidrRange(a.cluster)
+	for _, c := range cs {
+		o := cidrRange(c)
+		if len(o.start) == len(r.start) && bytes.Compare(o.start, r.end) <= 0 && bytes.Compare(r.start, o.end) <= 0 {
+			arr = append(arr, c)
+		}
+	}
+	return arr
+}
@@ -0,0 +1,123 @@
+package cidr
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNodeAllocator(t *testing.T) {
+	a, err := NewNodeAllocator(ParseNoError("10.244.0.0/22"), 24)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 4, a.Blocks())
+
+	// the service CIDR and a host route overlap the cluster CIDR
+	overlapping := a.Overlapping([]*CIDR{
+		ParseNoError("10.96.0.0/12"),
+		ParseNoError("10.244.1.128/25"),
+		ParseNoError("192.168.0.0/16"),
+		ParseNoError("10.0.0.0/8"),
+		ParseNoError("::/0"),
+	})
+	assert.Equal(t, []string{"10.244.1.128/25", "10.0.0.0/8"}, cidrStrings(overlapping))
+	assert.NoError(t, a.Occupy(overlapping[0]))
+	assert.Error(t, a.Occupy(ParseNoError("10.96.0.0/12")))
+	assert.True(t, a.IsAllocated(ParseNoError("10.244.1.0/24")))
+	assert.Equal(t, 1, a.Used())
+
+	c, err := a.Allocate()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.244.0.0/24", c.String())
+	c, err = a.Allocate()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.244.2.0/24", c.String())
+
+	// round-robin, a released block is not reused at once
+	assert.NoError(t, a.Release(ParseNoError("10.244.0.0/24")))
+	assert.False(t, a.IsAllocated(ParseNoError("10.244.0.0/24")))
+	c, err = a.Allocate()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.244.3.0/24", c.String())
+	c, err = a.Allocate()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.244.0.0/24", c.String())
+	assert.Equal(t, 0, a.Free())
+	_, err = a.Allocate()
+	assert.Error(t, err)
+
+	// a part of a block can not be released
+	assert.Error(t, a.Release(ParseNoError("10.244.1.128/25")))
+	assert.True(t, a.IsAllocated(ParseNoError("10.244.1.0/24")))
+	assert.Error(t, a.Release(ParseNoError("2001:db8::/64")))
+
+	// releasing a larger CIDR releases all the blocks in it
+	assert.NoError(t, a.Release(ParseNoError("10.244.2.0/23")))
+	assert.Equal(t, []string{"10.244.0.0/24", "10.244.1.0/24"}, cidrStrings(a.Allocated()))
+	assert.Equal(t, 2, a.Free())
+}
+
+func TestNodeAllocator_State(t *testing.T) {
+	a, _ := NewNodeAllocator(ParseNoError("fd00:10:244::/56"), 64)
+	assert.Equal(t, 256, a.Blocks())
+	for i := 0; i < 10; i++ {
+		_, _ = a.Allocate()
+	}
+	_ = a.Release(ParseNoError("fd00:10:244:3::/64"))
+
+	data, err := json.Marshal(a.State())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"clusterCIDR":"fd00:10:244::/56","nodeMaskSize":64,"bitmap":"3f7","next":10}`, string(data))
+
+	var state NodeAllocatorState
+	assert.NoError(t, json.Unmarshal(data, &state))
+	b, err := NewNodeAllocatorFromState(state)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 9, b.Used())
+	assert.Equal(t, cidrStrings(a.Allocated()), cidrStrings(b.Allocated()))
+	c, _ := b.Allocate()
+	assert.Equal(t, "fd00:10:244:a::/64", c.String())
+
+	state.Bitmap = "xyz"
+	_, err = NewNodeAllocatorFromState(state)
+	assert.Error(t, err)
+	state.Bitmap = "10000000000000000000000000000000000000000000000000000000000000000"
+	_, err = NewNodeAllocatorFromState(state)
+	assert.Error(t, err)
+	state.Bitmap, state.Next = "", 256
+	_, err = NewNodeAllocatorFromState(state)
+	assert.Error(t, err)
+}
+
+func TestNodeAllocator_IPv4Mapped(t *testing.T) {
+	a, err := NewNodeAllocator(ParseNoError("::ffff:10.244.0.0/112"), 120)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "10.244.0.0/16", a.ClusterCIDR().String())
+	assert.Equal(t, 256, a.Blocks())
+	c, err := a.Allocate()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.244.0.0/24", c.String())
+	assert.NoError(t, a.Occupy(ParseNoError("::ffff:10.244.1.0/120")))
+	assert.True(t, a.IsAllocated(ParseNoError("10.244.1.0/24")))
+	assert.Equal(t, 24, a.State().NodeMaskSize)
+
+	_, err = NewNodeAllocator(ParseNoError("::ffff:10.244.0.0/112"), 24)
+	assert.Error(t, err)
+}
+
+func TestNewNodeAllocator_Error(t *testing.T) {
+	_, err := NewNodeAllocator(ParseNoError("10.0.0.0/8"), 7)
+	assert.Error(t, err)
+	_, err = NewNodeAllocator(ParseNoError("10.0.0.0/8"), 33)
+	assert.Error(t, err)
+	_, err = NewNodeAllocator(ParseNoError("10.0.0.0/8"), 25)
+	assert.Error(t, err)
+	a, err := NewNodeAllocator(ParseNoError("10.0.0.0/8"), 24)
+	assert.NoError(t, err)
+	assert.Equal(t, 65536, a.Blocks())
+}
@@ -0,0 +1,231 @@
+package cidr
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Protocol is an IP protocol number
+type Protocol uint8
+
+const (
+	// ProtoAny matches any protocol in a rule
+	ProtoAny    = Protocol(0)
+	ProtoICMP   = Protocol(1)
+	ProtoTCP    = Protocol(6)
+	ProtoUDP    = Protocol(17)
+	ProtoICMPv6 = Protocol(58)
+)
+
+// String returns the name of the protocol, or its number if unknown
+func (p Protocol) String() string {
+	switch p {
+	case ProtoAny:
+		return "any"
+	case ProtoICMP:
+		return "icmp"
+	case ProtoTCP:
+		return "tcp"
+	case ProtoUDP:
+		return "udp"
+	case ProtoICMPv6:
+		return "icmpv6"
+	}
+	return strconv.Itoa(int(p))
+}
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	From uint16
+	To   uint16
+}
+
+// ParsePortRange parses a port like "443" or a port range like "8000-8080"
+func ParsePortRange(s string) (PortRange, error) {
+	from, to := s, s
+	if pos := strings.IndexByte(s, '-'); pos != -1 {
+		from, to = s[:pos], s[pos+1:]
+	}
+	a, err := strconv.ParseUint(strings.TrimSpace(from), 10, 16)
+	if err != nil {
+		return PortRange{}, fmt.Errorf("invalid port range: %v", s)
+	}
+	b, err := strconv.ParseUint(strings.TrimSpace(to), 10, 16)
+	if err != nil || b < a {
+		return PortRange{}, fmt.Errorf("invalid port range: %v", s)
+	}
+	return PortRange{From: uint16(a), To: uint16(b)}, nil
+}
+
+// Contains reports whether port is in the range
+func (r PortRange) Contains(port uint16) bool {
+	return r.From <= port && port <= r.To
+}
+
+// String returns the port range like "8000-8080", or the port like "443" if From equals To
+func (r PortRange) String() string {
+	if r.From == r.To {
+		return strconv.Itoa(int(r.From))
+	}
+	return fmt.Sprintf("%d-%d", r.From, r.To)
+}
+
+// PolicyRule is a rule of a firewall policy, matching packets from any of Src to any of Dst
+// of Protocol to any of Ports.
+// 	An empty Src, Dst or Ports matches any, so does ProtoAny.
+type PolicyRule struct {
+	Name     string
+	Action   ACLAction
+	Src      []*CIDR
+	Dst      []*CIDR
+	Protocol Protocol
+	Ports    []PortRange
+}
+
+// matchPort reports whether the rule matches proto and port
+func (r *PolicyRule) matchPort(proto Protocol, port uint16) bool {
+	if r.Protocol != ProtoAny && r.Protocol != proto {
+		return false
+	}
+	if len(r.Ports) == 0 {
+		return true
+	}
+	for _, p := range r.Ports {
+		if p.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// portCount returns the number of ports the rule matches
+func (r *PolicyRule) portCount() int {
+	if len(r.Ports) == 0 {
+		return 1 << 16
+	}
+	n := 0
+	for _, p := range r.Ports {
+		n += int(p.To-p.From) + 1
+	}
+	return n
+}
+
+// PolicyMode is how a Policy chooses among the rules matching a packet
+type PolicyMode int
+
+const (
+	// PolicyFirstMatch the first matching rule in order decides
+	PolicyFirstMatch = PolicyMode(0)
+	// PolicyMostSpecific the matching rule of the most specific Src decides, then of the most specific Dst,
+	// then the one of a Protocol, then the one of the fewest ports, and then the first in order
+	PolicyMostSpecific = PolicyMode(1)
+)
+
+// anyCIDRs are the CIDRs matching any IP of both families
+var anyCIDRs = []*CIDR{newCIDR(net.IP{0, 0, 0, 0}, 0), newCIDR(make(net.IP, net.IPv6len), 0)}
+
+// Policy evaluates packets against firewall rules, the source and destination CIDRs of the rules
+// are indexed by prefix tries.
+// 	Policy is safe for concurrent use.
+type Policy struct {
+	mode  PolicyMode
+	rules []PolicyRule
+	src   *Trie
+	dst   *Trie
+}
+
+// NewPolicy returns a Policy of rules evaluated in mode
+func NewPolicy(mode PolicyMode, rules []PolicyRule) (*Policy, error) {
+	if mode != PolicyFirstMatch && mode != PolicyMostSpecific {
+		return nil, fmt.Errorf("invalid policy mode: %d", mode)
+	}
+	p := &Policy{mode: mode, rules: make([]PolicyRule, len(rules)), src: NewTrie(), dst: NewTrie()}
+	copy(p.rules, rules)
+	for i, rule := range p.rules {
+		for _, port := range rule.Ports {
+			if port.From > port.To {
+				return nil, fmt.Errorf("rule %d: invalid port range %d-%d", i, port.From, port.To)
+			}
+		}
+		p.index(p.src, rule.Src, i)
+		p.index(p.dst, rule.Dst, i)
+	}
+	return p, nil
+}
+
+// index adds rule i to the trie under cs
+func (p *Policy) index(t *Trie, cs []*CIDR, i int) {
+	if len(cs) == 0 {
+		cs = anyCIDRs
+	}
+	for _, c := range cs {
+		var arr []int
+		if value, ok := t.Get(c); ok {
+			arr = value.([]int)
+		}
+		t.Insert(c, append(arr, i))
+	}
+}
+
+// matches returns the rules having a CIDR which includes ip in t, with the mask length of the most specific one
+func (p *Policy) matches(t *Trie, ip net.IP) map[int]int {
+	m := map[int]int{}
+	t.eachCovering(ip, len(ip)*8, func(node *trieNode) bool {
+		ones, _ := node.cidr.ipNet.Mask.Size()
+		for _, i := range node.value.([]int) {
+			m[i] = ones
+		}
+		return true
+	})
+	return m
+}
+
+// Evaluate returns the rule deciding a packet from src to dst of proto to port, or nil if no rule matches.
+// 	The port is ignored by rules without Ports, use 0 for protocols without ports.
+func (p *Policy) Evaluate(src, dst string, proto Protocol, port uint16) *PolicyRule {
+	srcIP, dstIP := parseIP(src), parseIP(dst)
+	if srcIP == nil || dstIP == nil || len(srcIP) != len(dstIP) {
+		return nil
+	}
+	srcOnes := p.matches(p.src, srcIP)
+	if len(srcOnes) == 0 {
+		return nil
+	}
+	dstOnes := p.matches(p.dst, dstIP)
+
+	best := -1
+	for i, d := range dstOnes {
+		s, ok := srcOnes[i]
+		if !ok || !p.rules[i].matchPort(proto, port) {
+			continue
+		}
+		if best == -1 || p.better(i, s, d, best, srcOnes[best], dstOnes[best]) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return nil
+	}
+	return &p.rules[best]
+}
+
+// better reports whether rule i decides rather than rule j, with the mask lengths of their matching CIDRs
+func (p *Policy) better(i, iSrc, iDst, j, jSrc, jDst int) bool {
+	if p.mode == PolicyMostSpecific {
+		if iSrc != jSrc {
+			return iSrc > jSrc
+		}
+		if iDst != jDst {
+			return iDst > jDst
+		}
+		if a, b := p.rules[i].Protocol != ProtoAny, p.rules[j].Protocol != ProtoAny; a != b {
+			return a
+		}
+		if a, b := p.rules[i].portCount(), p.rules[j].portCount(); a != b {
+			return a < b
+		}
+	}
+	return i < j
+}
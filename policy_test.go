@@ -0,0 +1,76 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParsePortRange(t *testing.T) {
+	r, err := ParsePortRange("443")
+	assert.NoError(t, err)
+	assert.Equal(t, PortRange{From: 443, To: 443}, r)
+	assert.Equal(t, "443", r.String())
+
+	r, err = ParsePortRange("8000-8080")
+	assert.NoError(t, err)
+	assert.Equal(t, PortRange{From: 8000, To: 8080}, r)
+	assert.Equal(t, "8000-8080", r.String())
+	assert.True(t, r.Contains(8080))
+	assert.False(t, r.Contains(8081))
+
+	for _, s := range []string{"", "http", "65536", "8080-8000", "1-2-3"} {
+		_, err = ParsePortRange(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func policyRules() []PolicyRule {
+	return []PolicyRule{
+		{Name: "web", Action: ACLPermit, Dst: aclCIDRs("192.168.1.0/24", "2001:db8:1::/48"), Protocol: ProtoTCP,
+			Ports: []PortRange{{From: 80, To: 80}, {From: 443, To: 443}}},
+		{Name: "block-guest", Action: ACLDeny, Src: aclCIDRs("10.9.0.0/16")},
+		{Name: "office-ssh", Action: ACLPermit, Src: aclCIDRs("10.0.0.0/8"), Dst: aclCIDRs("192.168.0.0/16"),
+			Protocol: ProtoTCP, Ports: []PortRange{{From: 22, To: 22}}},
+		{Name: "admin", Action: ACLPermit, Src: aclCIDRs("10.9.1.10/32"), Dst: aclCIDRs("192.168.0.0/16")},
+		{Name: "ping", Action: ACLPermit, Protocol: ProtoICMP},
+	}
+}
+
+func TestPolicy_FirstMatch(t *testing.T) {
+	p, err := NewPolicy(PolicyFirstMatch, policyRules())
+	assert.NoError(t, err)
+
+	name := func(rule *PolicyRule) string {
+		if rule == nil {
+			return ""
+		}
+		return rule.Name
+	}
+	assert.Equal(t, "web", name(p.Evaluate("10.9.1.10", "192.168.1.5", ProtoTCP, 443)))
+	assert.Equal(t, "web", name(p.Evaluate("2001:db8::1", "2001:db8:1::5", ProtoTCP, 80)))
+	assert.Equal(t, "block-guest", name(p.Evaluate("10.9.1.10", "192.168.2.5", ProtoTCP, 22)))
+	assert.Equal(t, "office-ssh", name(p.Evaluate("10.1.0.1", "192.168.2.5", ProtoTCP, 22)))
+	assert.Equal(t, "ping", name(p.Evaluate("172.16.0.1", "8.8.8.8", ProtoICMP, 0)))
+	assert.Equal(t, "", name(p.Evaluate("10.1.0.1", "192.168.2.5", ProtoUDP, 53)))
+	assert.Equal(t, "", name(p.Evaluate("10.1.0.1", "2001:db8:1::5", ProtoTCP, 80)))
+	assert.Equal(t, "", name(p.Evaluate("abc", "192.168.1.5", ProtoTCP, 80)))
+}
+
+func TestPolicy_MostSpecific(t *testing.T) {
+	p, err := NewPolicy(PolicyMostSpecific, policyRules())
+	assert.NoError(t, err)
+
+	rule := p.Evaluate("10.9.1.10", "192.168.2.5", ProtoTCP, 22)
+	assert.Equal(t, "admin", rule.Name)
+	assert.Equal(t, ACLPermit, rule.Action)
+	assert.Equal(t, "block-guest", p.Evaluate("10.9.1.11", "192.168.2.5", ProtoTCP, 22).Name)
+	assert.Equal(t, "office-ssh", p.Evaluate("10.1.0.1", "192.168.2.5", ProtoTCP, 22).Name)
+	assert.Equal(t, "office-ssh", p.Evaluate("10.1.0.1", "192.168.1.5", ProtoTCP, 22).Name)
+	assert.Equal(t, "web", p.Evaluate("172.16.0.1", "192.168.1.5", ProtoTCP, 443).Name)
+	assert.Nil(t, p.Evaluate("172.16.0.1", "192.168.1.5", ProtoUDP, 443))
+
+	_, err = NewPolicy(PolicyMode(2), nil)
+	assert.Error(t, err)
+	_, err = NewPolicy(PolicyFirstMatch, []PolicyRule{{Ports: []PortRange{{From: 2, To: 1}}}})
+	assert.Error(t, err)
+}
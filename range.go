@@ -0,0 +1,245 @@
+package cidr
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Range is an inclusive range of IPs of the same family, like "192.168.1.10-192.168.1.20".
+// 	Unlike CIDR, a range is not required to be aligned to a mask.
+type Range struct {
+	start net.IP
+	end   net.IP
+}
+
+// NewRange returns the Range from start to end, which must be of the same family and start <= end
+func NewRange(start, end string) (*Range, error) {
+	startIP := parseIP(start)
+	if startIP == nil {
+		return nil, fmt.Errorf("invalid start ip: %v", start)
+	}
+	endIP := parseIP(end)
+	if endIP == nil {
+		return nil, fmt.Errorf("invalid end ip: %v", end)
+	}
+	if len(startIP) != len(endIP) {
+		return nil, fmt.Errorf("start and end ip are not the same family")
+	}
+	if bytes.Compare(startIP, endIP) > 0 {
+		return nil, fmt.Errorf("start ip is greater than end ip")
+	}
+	return &Range{start: startIP, end: endIP}, nil
+}
+
+// ParseRange parses s as a Range, s can be a range like "192.168.1.10-192.168.1.20",
+// a CIDR like "192.168.1.0/24" or a single IP
+func ParseRange(s string) (*Range, error) {
+	s = strings.TrimSpace(s)
+	if idx := strings.Index(s, "-"); idx >= 0 {
+		return NewRange(strings.TrimSpace(s[:idx]), strings.TrimSpace(s[idx+1:]))
+	}
+	if strings.Contains(s, "/") {
+		c, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		r := cidrRange(c)
+		return &r, nil
+	}
+	return NewRange(s, s)
+}
+
+// cidrRange returns the Range of all IPs in c
+func cidrRange(c *CIDR) Range {
+	return Range{start: normalizeIP(c.StartIP()), end: normalizeIP(c.EndIP())}
+}
+
+// String returns the string representation of the Range, like "192.168.1.10-192.168.1.20"
+func (r Range) String() string {
+	return r.start.String() + "-" + r.end.String()
+}
+
+// StartIP returns the start IP of the Range
+func (r Range) StartIP() net.IP {
+	return r.start
+}
+
+// EndIP returns the end IP of the Range
+func (r Range) EndIP() net.IP {
+	return r.end
+}
+
+// IsIPv4 reports whether the Range is IPv4
+func (r Range) IsIPv4() bool {
+	return len(r.start) == net.IPv4len
+}
+
+// IsIPv6 reports whether the Range is IPv6
+func (r Range) IsIPv6() bool {
+	return len(r.start) == net.IPv6len
+}
+
+// Contains reports whether the Range includes ip
+func (r Range) Contains(ip string) bool {
+	ipObj := parseIP(ip)
+	return ipObj != nil && r.containsIP(ipObj)
+}
+
+func (r Range) containsIP(ip net.IP) bool {
+	return len(ip) == len(r.start) && bytes.Compare(ip, r.start) >= 0 && bytes.Compare(ip, r.end) <= 0
+}
+
+// IPCount returns the number of IPs in the Range
+func (r Range) IPCount() *big.Int {
+	n := big.NewInt(0).Sub(ipToInt(r.end), ipToInt(r.start))
+	return n.Add(n, bigIntOne)
+}
+
+// CIDRs returns the minimal list of CIDRs covering exactly the Range, in ascending order
+func (r Range) CIDRs() []*CIDR {
+	bits := len(r.start) * 8
+	next, end := ipToInt(r.start), ipToInt(r.end)
+	var cidrArr []*CIDR
+	for next.Cmp(end) <= 0 {
+		// the largest block aligned at next, then shrink until it does not exceed end
+		hostBits := bits
+		if next.Sign() != 0 {
+			hostBits = int(next.TrailingZeroBits())
+		}
+		size := big.NewInt(0)
+		for {
+			size.Lsh(bigIntOne, uint(hostBits))
+			last := big.NewInt(0).Add(next, size)
+			if last.Sub(last, bigIntOne).Cmp(end) <= 0 {
+				break
+			}
+			hostBits--
+		}
+		cidrArr = append(cidrArr, newCIDR(intToIP(next, len(r.start)), bits-hostBits))
+		next.Add(next, size)
+	}
+	return cidrArr
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	return big.NewInt(0).SetBytes(ip)
+}
+
+func intToIP(n *big.Int, size int) net.IP {
+	ip := make(net.IP, size)
+	b := n.Bytes()
+	if len(b) > size {
+		b = b[len(b)-size:]
+	}
+	copy(ip[size-len(b):], b)
+	return ip
+}
+
+// compareRange orders ranges by family (IPv4 first) and then by start IP
+func compareRange(a, b Range) int {
+	if len(a.start) != len(b.start) {
+		if len(a.start) < len(b.start) {
+			return -1
+		}
+		return 1
+	}
+	return bytes.Compare(a.start, b.start)
+}
+
+// mergeRanges returns the sorted union of rs, with overlapping and adjacent ranges merged
+func mergeRanges(rs []Range) []Range {
+	if len(rs) == 0 {
+		return nil
+	}
+	sorted := make([]Range, len(rs))
+	copy(sorted, rs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return compareRange(sorted[i], sorted[j]) < 0
+	})
+
+	merged := []Range{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if len(last.start) == len(r.start) {
+			if next := IPIncr2(last.end); bytes.Compare(r.start, last.end) <= 0 || bytes.Equal(r.start, next) {
+				if bytes.Compare(r.end, last.end) > 0 {
+					last.end = r.end
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// subtractRanges returns the IPs of a which are not in b, both must be merged
+func subtractRanges(a, b []Range) []Range {
+	var result []Range
+	j := 0
+	for _, r := range a {
+		cur := r
+		for ; j < len(b) && compareRange(Range{start: b[j].end}, Range{start: cur.start}) < 0; j++ {
+		}
+		empty := false
+		for k := j; k < len(b) && len(b[k].start) == len(cur.start) && bytes.Compare(b[k].start, cur.end) <= 0; k++ {
+			if bytes.Compare(b[k].start, cur.start) > 0 {
+				result = append(result, Range{start: cur.start, end: IPDecr2(b[k].start)})
+			}
+			if bytes.Compare(b[k].end, cur.end) >= 0 {
+				empty = true
+				break
+			}
+			cur.start = IPIncr2(b[k].end)
+		}
+		if !empty {
+			result = append(result, cur)
+		}
+	}
+	return result
+}
+
+// intersectRanges returns the IPs in both a and b, both must be merged
+func intersectRanges(a, b []Range) []Range {
+	var result []Range
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if len(a[i].start) != len(b[j].start) {
+			if len(a[i].start) < len(b[j].start) {
+				i++
+			} else {
+				j++
+			}
+			continue
+		}
+		start, end := a[i].start, a[i].end
+		if bytes.Compare(b[j].start, start) > 0 {
+			start = b[j].start
+		}
+		if bytes.Compare(b[j].end, end) < 0 {
+			end = b[j].end
+		}
+		if bytes.Compare(start, end) <= 0 {
+			result = append(result, Range{start: start, end: end})
+		}
+		if bytes.Compare(a[i].end, b[j].end) < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// rangesToCIDRs returns the minimal list of CIDRs covering rs, which must be merged
+func rangesToCIDRs(rs []Range) []*CIDR {
+	var cidrArr []*CIDR
+	for _, r := range rs {
+		cidrArr = append(cidrArr, r.CIDRs()...)
+	}
+	return cidrArr
+}
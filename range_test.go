@@ -0,0 +1,97 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func cidrStrings(cs []*CIDR) []string {
+	arr := make([]string, 0, len(cs))
+	for _, c := range cs {
+		arr = append(arr, c.String())
+	}
+	return arr
+}
+
+func TestParseRange(t *testing.T) {
+	r, err := ParseRange("192.168.1.10-192.168.1.20")
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.1.10-192.168.1.20", r.String())
+	assert.Equal(t, int64(11), r.IPCount().Int64())
+	assert.Equal(t, true, r.IsIPv4())
+
+	r, err = ParseRange("192.168.1.0/24")
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.1.0-192.168.1.255", r.String())
+
+	r, err = ParseRange("2001:db8::1")
+	assert.NoError(t, err)
+	assert.Equal(t, "2001:db8::1-2001:db8::1", r.String())
+	assert.Equal(t, true, r.IsIPv6())
+
+	_, err = ParseRange("192.168.1.20-192.168.1.10")
+	assert.Error(t, err)
+	_, err = ParseRange("192.168.1.1-2001:db8::1")
+	assert.Error(t, err)
+	_, err = ParseRange("invalid")
+	assert.Error(t, err)
+}
+
+func TestRange_Contains(t *testing.T) {
+	r, _ := ParseRange("192.168.1.10-192.168.1.20")
+	assert.Equal(t, true, r.Contains("192.168.1.10"))
+	assert.Equal(t, true, r.Contains("192.168.1.20"))
+	assert.Equal(t, false, r.Contains("192.168.1.21"))
+	assert.Equal(t, false, r.Contains("::ffff:192.168.1.9"))
+	assert.Equal(t, false, r.Contains("2001:db8::1"))
+}
+
+func TestRange_CIDRs(t *testing.T) {
+	tests := []struct {
+		r      string
+		expect []string
+	}{
+		{"192.168.1.0-192.168.1.255", []string{"192.168.1.0/24"}},
+		{"192.168.1.10-192.168.1.20", []string{"192.168.1.10/31", "192.168.1.12/30", "192.168.1.16/30", "192.168.1.20/32"}},
+		{"0.0.0.0-255.255.255.255", []string{"0.0.0.0/0"}},
+		{"255.255.255.255-255.255.255.255", []string{"255.255.255.255/32"}},
+		{"1.0.0.0-1.0.2.255", []string{"1.0.0.0/23", "1.0.2.0/24"}},
+		{"2001:db8::-2001:db8::2", []string{"2001:db8::/127", "2001:db8::2/128"}},
+		{"::-ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", []string{"::/0"}},
+	}
+	for _, test := range tests {
+		r, err := ParseRange(test.r)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expect, cidrStrings(r.CIDRs()), test.r)
+	}
+}
+
+func TestRanges(t *testing.T) {
+	parse := func(arr ...string) []Range {
+		var rs []Range
+		for _, s := range arr {
+			r, _ := ParseRange(s)
+			rs = append(rs, *r)
+		}
+		return rs
+	}
+	format := func(rs []Range) []string {
+		var arr []string
+		for _, r := range rs {
+			arr = append(arr, r.String())
+		}
+		return arr
+	}
+
+	merged := mergeRanges(parse("2001:db8::/64", "10.0.0.10-10.0.0.20", "10.0.0.0-10.0.0.9", "10.0.0.15-10.0.0.30", "10.0.1.0/24"))
+	assert.Equal(t, []string{"10.0.0.0-10.0.0.30", "10.0.1.0-10.0.1.255", "2001:db8::-2001:db8::ffff:ffff:ffff:ffff"}, format(merged))
+
+	diff := subtractRanges(merged, mergeRanges(parse("10.0.0.5", "10.0.0.25-10.0.1.10", "2001:db8::/65")))
+	assert.Equal(t, []string{"10.0.0.0-10.0.0.4", "10.0.0.6-10.0.0.24", "10.0.1.11-10.0.1.255", "2001:db8:0:0:8000::-2001:db8::ffff:ffff:ffff:ffff"}, format(diff))
+
+	diff = subtractRanges(parse("10.0.0.0/24"), parse("10.0.0.0/16"))
+	assert.Equal(t, []string(nil), format(diff))
+
+	inter := intersectRanges(merged, mergeRanges(parse("10.0.0.25-10.0.1.10", "2001:db8::1")))
+	assert.Equal(t, []string{"10.0.0.25-10.0.0.30", "10.0.1.0-10.0.1.10", "2001:db8::1-2001:db8::1"}, format(inter))
+}
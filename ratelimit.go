@@ -0,0 +1,151 @@
+package cidr
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// RateKeyer maps IPs to the prefix they are rate limited by, as a client can rotate its IPv6 address within
+// its /64 (or even its /56 or /48) at will, rate limiting by a single IPv6 address is useless.
+type RateKeyer struct {
+	ipv4Ones int
+	ipv6Ones int
+}
+
+// NewRateKeyer returns a RateKeyer masking IPv4 to ipv4Ones and IPv6 to ipv6Ones, like 32 and 64
+func NewRateKeyer(ipv4Ones, ipv6Ones int) (*RateKeyer, error) {
+	if ipv4Ones < 0 || ipv4Ones > 8*net.IPv4len {
+		return nil, fmt.Errorf("invalid ipv4 prefix length: %d", ipv4Ones)
+	}
+	if ipv6Ones < 0 || ipv6Ones > 8*net.IPv6len {
+		return nil, fmt.Errorf("invalid ipv6 prefix length: %d", ipv6Ones)
+	}
+	return &RateKeyer{ipv4Ones: ipv4Ones, ipv6Ones: ipv6Ones}, nil
+}
+
+// prefix returns the prefix of ip, which must be normalized
+func (k *RateKeyer) prefix(ip net.IP) *CIDR {
+	if len(ip) == net.IPv4len {
+		return newCIDR(ip, k.ipv4Ones)
+	}
+	return newCIDR(ip, k.ipv6Ones)
+}
+
+// KeyFor returns the rate limit key of ip, the prefix including it like "2001:db8:1:2::/64".
+// 	IPv4-mapped IPv6 addresses are keyed as IPv4.
+func (k *RateKeyer) KeyFor(ip string) (string, error) {
+	ipObj := parseIP(ip)
+	if ipObj == nil {
+		return "", fmt.Errorf("invalid ip: %v", ip)
+	}
+	return k.prefix(ipObj).String(), nil
+}
+
+// RateLimit is a level of a HierarchicalLimiter, allowing Limit requests per window by prefix of
+// IPv4Ones and IPv6Ones
+type RateLimit struct {
+	IPv4Ones int
+	IPv6Ones int
+	Limit    int
+}
+
+// rateCounter is the number of requests of a prefix in the window starting at start
+type rateCounter struct {
+	start time.Time
+	count int
+}
+
+// rateLevel is a level of a HierarchicalLimiter
+type rateLevel struct {
+	keyer    *RateKeyer
+	limit    int
+	counters map[string]*rateCounter
+}
+
+// HierarchicalLimiter limits requests at several prefix lengths simultaneously, like 10 requests per /64,
+// 100 per /56 and 1000 per /48, so that neither a client rotating its address nor a whole network
+// can exceed its share.
+// 	Requests are counted in fixed windows. HierarchicalLimiter is safe for concurrent use.
+type HierarchicalLimiter struct {
+	window time.Duration
+	levels []*rateLevel
+	now    func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewHierarchicalLimiter returns a HierarchicalLimiter allowing requests within all the limits per window
+func NewHierarchicalLimiter(window time.Duration, limits []RateLimit) (*HierarchicalLimiter, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid window: %v", window)
+	}
+	if len(limits) == 0 {
+		return nil, fmt.Errorf("no limit")
+	}
+	l := &HierarchicalLimiter{window: window, now: time.Now}
+	for i, limit := range limits {
+		keyer, err := NewRateKeyer(limit.IPv4Ones, limit.IPv6Ones)
+		if err != nil {
+			return nil, fmt.Errorf("limit %d: %v", i, err)
+		}
+		if limit.Limit <= 0 {
+			return nil, fmt.Errorf("limit %d: invalid limit %d", i, limit.Limit)
+		}
+		l.levels = append(l.levels, &rateLevel{keyer: keyer, limit: limit.Limit, counters: map[string]*rateCounter{}})
+	}
+	return l, nil
+}
+
+// Allow reports whether a request of ip is within all the limits, and counts it if so.
+// 	Denied requests are not counted.
+func (l *HierarchicalLimiter) Allow(ip string) (bool, error) {
+	ipObj := parseIP(ip)
+	if ipObj == nil {
+		return false, fmt.Errorf("invalid ip: %v", ip)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := l.now().Truncate(l.window)
+	l.sweep(start)
+
+	counters := make([]*rateCounter, len(l.levels))
+	for i, level := range l.levels {
+		key := level.keyer.prefix(ipObj).String()
+		counter := level.counters[key]
+		if counter == nil {
+			counter = &rateCounter{}
+			level.counters[key] = counter
+		}
+		if !counter.start.Equal(start) {
+			counter.start, counter.count = start, 0
+		}
+		if counter.count >= level.limit {
+			return false, nil
+		}
+		counters[i] = counter
+	}
+	for _, counter := range counters {
+		counter.count++
+	}
+	return true, nil
+}
+
+// sweep removes the counters of past windows, once per window, l.mu must be held
+func (l *HierarchicalLimiter) sweep(start time.Time) {
+	if !start.After(l.lastSweep) {
+		return
+	}
+	l.lastSweep = start
+	for _, level := range l.levels {
+		for key, counter := range level.counters {
+			if counter.start.Before(start) {
+				delete(level.counters, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRateKeyer_KeyFor(t *testing.T) {
+	k, err := NewRateKeyer(32, 64)
+	assert.NoError(t, err)
+	for ip, key := range map[string]string{
+		"192.0.2.1":             "192.0.2.1/32",
+		"::ffff:192.0.2.1":      "192.0.2.1/32",
+		"2001:db8:1:2:a:b:c:d":  "2001:db8:1:2::/64",
+		"2001:db8:1:2:ffff::1":  "2001:db8:1:2::/64",
+		"2001:db8:1:3:a:b:c:d":  "2001:db8:1:3::/64",
+		"fe80::1234:5678:9abc:": "",
+	} {
+		got, err := k.KeyFor(ip)
+		if key == "" {
+			assert.Error(t, err, ip)
+			continue
+		}
+		assert.NoError(t, err, ip)
+		assert.Equal(t, key, got, ip)
+	}
+
+	k, _ = NewRateKeyer(24, 48)
+	key, _ := k.KeyFor("192.0.2.200")
+	assert.Equal(t, "192.0.2.0/24", key)
+	key, _ = k.KeyFor("2001:db8:1:ff00::1")
+	assert.Equal(t, "2001:db8:1::/48", key)
+
+	_, err = NewRateKeyer(33, 64)
+	assert.Error(t, err)
+	_, err = NewRateKeyer(32, -1)
+	assert.Error(t, err)
+}
+
+func TestHierarchicalLimiter(t *testing.T) {
+	l, err := NewHierarchicalLimiter(time.Minute, []RateLimit{
+		{IPv4Ones: 32, IPv6Ones: 64, Limit: 2},
+		{IPv4Ones: 24, IPv6Ones: 56, Limit: 3},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	allow := func(ip string) bool {
+		ok, err := l.Allow(ip)
+		assert.NoError(t, err)
+		return ok
+	}
+
+	// rotating the address within the /64 does not help
+	assert.True(t, allow("2001:db8:0:1::1"))
+	assert.True(t, allow("2001:db8:0:1::2"))
+	assert.False(t, allow("2001:db8:0:1::3"))
+
+	// another /64 of the same /56 is limited by the /56 level
+	assert.True(t, allow("2001:db8:0:2::1"))
+	assert.False(t, allow("2001:db8:0:3::1"))
+	assert.True(t, allow("2001:db8:1::1"))
+
+	assert.True(t, allow("192.0.2.1"))
+	assert.True(t, allow("192.0.2.1"))
+	assert.False(t, allow("192.0.2.1"))
+	assert.True(t, allow("192.0.2.2"))
+	assert.False(t, allow("192.0.2.3"))
+
+	// a new window
+	now = now.Add(time.Minute)
+	assert.True(t, allow("192.0.2.3"))
+	assert.True(t, allow("2001:db8:0:1::3"))
+	assert.Equal(t, 2, len(l.levels[0].counters))
+
+	_, err = l.Allow("invalid")
+	assert.Error(t, err)
+
+	_, err = NewHierarchicalLimiter(0, []RateLimit{{IPv4Ones: 32, IPv6Ones: 64, Limit: 1}})
+	assert.Error(t, err)
+	_, err = NewHierarchicalLimiter(time.Second, nil)
+	assert.Error(t, err)
+	_, err = NewHierarchicalLimiter(time.Second, []RateLimit{{IPv4Ones: 32, IPv6Ones: 64}})
+	assert.Error(t, err)
+	_, err = NewHierarchicalLimiter(time.Second, []RateLimit{{IPv4Ones: 32, IPv6Ones: 129, Limit: 1}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,309 @@
+package cidr
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// regexpHexNonZero matches a non-zero IPv6 hextet in canonical form, without leading zeros
+const regexpHexNonZero = "[1-9a-f][0-9a-f]{0,3}"
+
+// regexpNone matches nothing
+const regexpNone = `^[^\s\S]$`
+
+// regexpDigitClass returns the class of the digits from x to y in base 10 or 16
+func regexpDigitClass(x, y, base int) string {
+	const digits = "0123456789abcdef"
+	if x == y {
+		return digits[x : x+1]
+	}
+	var sb strings.Builder
+	sb.WriteByte('[')
+	piece := func(a, b int) {
+		switch {
+		case a > b:
+		case a == b:
+			sb.WriteByte(digits[a])
+		case a+1 == b:
+			sb.WriteByte(digits[a])
+			sb.WriteByte(digits[b])
+		default:
+			sb.WriteByte(digits[a])
+			sb.WriteByte('-')
+			sb.WriteByte(digits[b])
+		}
+	}
+	if x <= 9 {
+		if y < 9 {
+			piece(x, y)
+		} else {
+			piece(x, 9)
+		}
+	}
+	if y >= 10 {
+		if x > 10 {
+			piece(x, y)
+		} else {
+			piece(10, y)
+		}
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// regexpSameLen returns the alternatives matching the numbers from a to b, written in base with the same length
+func regexpSameLen(a, b string, base int) []string {
+	digit := func(c byte) int {
+		n, _ := strconv.ParseUint(string(c), base, 8)
+		return int(n)
+	}
+	if len(a) == 1 {
+		return []string{regexpDigitClass(digit(a[0]), digit(b[0]), base)}
+	}
+	if a[0] == b[0] {
+		var arr []string
+		for _, s := range regexpSameLen(a[1:], b[1:], base) {
+			arr = append(arr, a[:1]+s)
+		}
+		return arr
+	}
+
+	n := len(a) - 1
+	zeros := strings.Repeat("0", n)
+	nines := strings.Repeat(strconv.FormatInt(int64(base-1), base), n)
+	var arr []string
+	first, last := digit(a[0]), digit(b[0])
+	if a[1:] != zeros {
+		for _, s := range regexpSameLen(a[1:], nines, base) {
+			arr = append(arr, a[:1]+s)
+		}
+		first++
+	}
+	if b[1:] != nines {
+		last--
+	}
+	if first <= last {
+		rest := regexpDigitClass(0, base-1, base)
+		if n > 1 {
+			rest += "{" + strconv.Itoa(n) + "}"
+		}
+		arr = append(arr, regexpDigitClass(first, last, base)+rest)
+	}
+	if b[1:] != nines {
+		for _, s := range regexpSameLen(zeros, b[1:], base) {
+			arr = append(arr, b[:1]+s)
+		}
+	}
+	return arr
+}
+
+// regexpRange returns a regular expression matching the numbers from lo to hi written in base without leading zeros
+func regexpRange(lo, hi, base int) string {
+	var arr []string
+	// the numbers of each length
+	for lower, upper := 0, base-1; lower <= hi; lower, upper = upper+1, upper*base+base-1 {
+		a, b := lo, hi
+		if a < lower {
+			a = lower
+		}
+		if b > upper {
+			b = upper
+		}
+		if a <= b {
+			arr = append(arr, regexpSameLen(strconv.FormatInt(int64(a), base), strconv.FormatInt(int64(b), base), base)...)
+		}
+	}
+	if len(arr) == 1 {
+		return arr[0]
+	}
+	return "(?:" + strings.Join(arr, "|") + ")"
+}
+
+// regexpNode is a node of a trie of regular expression tokens
+type regexpNode struct {
+	order    []string
+	children map[string]*regexpNode
+	end      bool
+}
+
+func (n *regexpNode) insert(tokens []string) {
+	for _, token := range tokens {
+		if n.children == nil {
+			n.children = map[string]*regexpNode{}
+		}
+		child := n.children[token]
+		if child == nil {
+			child = &regexpNode{}
+			n.children[token] = child
+			n.order = append(n.order, token)
+		}
+		n = child
+	}
+	n.end = true
+}
+
+// render returns the regular expression of the subtree, the tokens leading to the same subexpression are merged
+func (n *regexpNode) render() string {
+	var suffixes []string
+	groups := map[string][]string{}
+	for _, token := range n.order {
+		suffix := n.children[token].render()
+		if _, ok := groups[suffix]; !ok {
+			suffixes = append(suffixes, suffix)
+		}
+		groups[suffix] = append(groups[suffix], token)
+	}
+
+	parts := make([]string, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		tokens := groups[suffix]
+		if len(tokens) == 1 {
+			parts = append(parts, tokens[0]+suffix)
+		} else {
+			parts = append(parts, "(?:"+strings.Join(tokens, "|")+")"+suffix)
+		}
+	}
+	switch {
+	case len(parts) == 0:
+		return ""
+	case n.end:
+		return "(?:" + strings.Join(parts, "|") + ")?"
+	case len(parts) == 1:
+		return parts[0]
+	}
+	return "(?:" + strings.Join(parts, "|") + ")"
+}
+
+// regexpIPv4Tokens returns the tokens of the IPv4 addresses of c
+func regexpIPv4Tokens(c *CIDR) []string {
+	ip, ones := trieKey(c)
+	var tokens []string
+	for i, b := range ip {
+		if i > 0 {
+			tokens = append(tokens, `\.`)
+		}
+		switch n := ones - i*8; {
+		case n >= 8:
+			tokens = append(tokens, strconv.Itoa(int(b)))
+		case n <= 0:
+			tokens = append(tokens, regexpRange(0, 255, 10))
+		default:
+			hostMask := 0xff >> uint(n)
+			tokens = append(tokens, regexpRange(int(b), int(b)|hostMask, 10))
+		}
+	}
+	return tokens
+}
+
+// regexpHextet is a choice of an IPv6 hextet
+type regexpHextet struct {
+	zero    bool
+	pattern string
+}
+
+// regexpIPv6Tokens returns the tokens of the canonical IPv6 addresses of c (RFC 5952), one list per combination
+// of zero and non-zero hextets, as the zero hextets decide where "::" is
+func regexpIPv6Tokens(c *CIDR) [][]string {
+	ip, ones := trieKey(c)
+	choices := make([][]regexpHextet, 8)
+	for i := range choices {
+		v := int(ip[2*i])<<8 | int(ip[2*i+1])
+		switch n := ones - i*16; {
+		case n >= 16:
+			choices[i] = []regexpHextet{{v == 0, strconv.FormatInt(int64(v), 16)}}
+		case n <= 0:
+			choices[i] = []regexpHextet{{true, "0"}, {false, regexpHexNonZero}}
+		default:
+			lo, hi := v, v|0xffff>>uint(n)
+			if lo == 0 {
+				choices[i] = append(choices[i], regexpHextet{true, "0"})
+				lo = 1
+			}
+			if lo == 1 && hi == 0xffff {
+				choices[i] = append(choices[i], regexpHextet{false, regexpHexNonZero})
+			} else {
+				choices[i] = append(choices[i], regexpHextet{false, regexpRange(lo, hi, 16)})
+			}
+		}
+	}
+
+	var arr [][]string
+	hextets := make([]regexpHextet, 8)
+	var walk func(i int)
+	walk = func(i int) {
+		if i < 8 {
+			for _, h := range choices[i] {
+				hextets[i] = h
+				walk(i + 1)
+			}
+			return
+		}
+
+		// the longest run of at least 2 zero hextets is compressed, the first one if equal
+		start, length := -1, 1
+		for j := 0; j < 8; {
+			k := j
+			for k < 8 && hextets[k].zero {
+				k++
+			}
+			if k-j > length {
+				start, length = j, k-j
+			}
+			if k == j {
+				k++
+			}
+			j = k
+		}
+
+		var tokens []string
+		for j := 0; j < 8; j++ {
+			if j == start {
+				tokens = append(tokens, "::")
+				j += length - 1
+				continue
+			}
+			if j > 0 && j != start+length {
+				tokens = append(tokens, ":")
+			}
+			tokens = append(tokens, hextets[j].pattern)
+		}
+		arr = append(arr, tokens)
+	}
+	walk(0)
+	return arr
+}
+
+// regexpOf returns the anchored regular expression matching the textual addresses of cs
+func regexpOf(cs []*CIDR) string {
+	root := &regexpNode{}
+	for _, c := range cs {
+		// IPv4-mapped as IPv4, as net.IP.String prints them
+		if ip, _ := trieKey(c); len(ip) == net.IPv4len {
+			root.insert(regexpIPv4Tokens(c))
+			continue
+		}
+		for _, tokens := range regexpIPv6Tokens(c) {
+			root.insert(tokens)
+		}
+	}
+	if len(root.order) == 0 {
+		return regexpNone
+	}
+	return "^" + root.render() + "$"
+}
+
+// Regexp returns an anchored regular expression matching exactly the textual addresses of the CIDR,
+// for log systems only accepting regular expressions.
+// 	IPv4 addresses are matched in dotted decimal without leading zeros, IPv6 addresses in the canonical form
+// of RFC 5952, lower case, without leading zeros and with the longest run of zero hextets compressed,
+// as net.IP.String prints them, except IPv4-mapped addresses.
+// The expression is compatible with RE2 (Go regexp) and PCRE.
+func (c CIDR) Regexp() string {
+	return regexpOf([]*CIDR{&c})
+}
+
+// Regexp returns an anchored regular expression matching exactly the textual addresses of the set, see CIDR.Regexp
+func (s *IPSet) Regexp() string {
+	return regexpOf(s.CIDRs())
+}
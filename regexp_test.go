@@ -0,0 +1,100 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"net"
+	"regexp"
+	"testing"
+)
+
+// randomRegexpIP returns a random IP of size bytes, half of the IPv6 hextets are zero,
+// the first ones bits are copied from prefix
+func randomRegexpIP(r *rand.Rand, prefix net.IP, ones int) net.IP {
+	ip := make(net.IP, len(prefix))
+	r.Read(ip)
+	if len(ip) == net.IPv6len {
+		for i := 0; i < len(ip); i += 2 {
+			if r.Intn(2) == 0 {
+				ip[i], ip[i+1] = 0, 0
+			}
+		}
+	} else if r.Intn(4) == 0 {
+		ip[r.Intn(4)] = 0
+	}
+	for i := 0; i < ones; i++ {
+		setIPBit(ip, i, ipBit(prefix, i))
+	}
+	return ip
+}
+
+func TestCIDR_Regexp(t *testing.T) {
+	assert.Equal(t, `^10\.0\.[0-3]\.(?:[0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])$`,
+		ParseNoError("10.0.0.0/22").Regexp())
+	assert.Equal(t, `^192\.0\.2\.1$`, ParseNoError("192.0.2.1/32").Regexp())
+	assert.Equal(t, `^2001:db8::(?:1)?$`, ParseNoError("2001:db8::/127").Regexp())
+	assert.Equal(t, `^192\.0\.2\.1$`, ParseNoError("::ffff:192.0.2.1/128").Regexp())
+
+	r := rand.New(rand.NewSource(1))
+	for _, s := range []string{
+		"0.0.0.0/0", "10.0.0.0/8", "172.16.0.0/12", "192.168.1.0/24", "192.0.2.100/30", "100.64.0.0/10",
+		"198.51.100.7/32", "255.255.255.254/31",
+		"::/0", "::/127", "::/16", "2001:db8::/32", "2001:db8:8000::/33", "2001:db8:0:1::/64", "fe80::/10",
+		"2001:db8::/127", "2001:0:0:1::/112", "1:0:0:0:0:0:0:0/120", "ff00::/8", "::ffff:10.0.0.0/104",
+	} {
+		c := ParseNoError(s)
+		re := regexp.MustCompile(c.Regexp())
+		prefix, ones := trieKey(c)
+		for i := 0; i < 2000; i++ {
+			// inside, then mostly outside with the same prefix but the last bit of the prefix
+			var ip net.IP
+			if i%2 == 0 {
+				ip = randomRegexpIP(r, prefix, ones)
+			} else {
+				ip = randomRegexpIP(r, prefix, r.Intn(ones+1))
+			}
+			if len(ip) == net.IPv6len && ip.To4() != nil {
+				// IPv4-mapped addresses are printed as IPv4
+				continue
+			}
+			text := ip.String()
+			if !assert.Equal(t, c.Contains(text), re.MatchString(text), "%v %v", s, text) {
+				break
+			}
+		}
+		// leading zeros and upper case are not canonical
+		assert.False(t, re.MatchString("010.0.0.1"), s)
+		assert.False(t, re.MatchString("2001:DB8::1"), s)
+		assert.False(t, re.MatchString("2001:db8:0:0::1"), s)
+	}
+}
+
+func TestIPSet_Regexp(t *testing.T) {
+	s := NewIPSet([]*CIDR{
+		ParseNoError("10.0.0.0/24"),
+		ParseNoError("10.0.1.0/24"),
+		ParseNoError("192.168.0.0/25"),
+		ParseNoError("2001:db8::/48"),
+		ParseNoError("2001:db8:1::/48"),
+	})
+	re := regexp.MustCompile(s.Regexp())
+	for ip, ok := range map[string]bool{
+		"10.0.0.1":        true,
+		"10.0.1.255":      true,
+		"10.0.2.1":        false,
+		"192.168.0.127":   true,
+		"192.168.0.128":   false,
+		"2001:db8::1":     true,
+		"2001:db8:1:2::1": true,
+		"2001:db8:2::1":   false,
+		"2001:db8::":      true,
+		"::1":             false,
+	} {
+		assert.Equal(t, ok, re.MatchString(ip), ip)
+		assert.Equal(t, ok, s.Contains(ip), ip)
+	}
+
+	re = regexp.MustCompile(NewIPSet(nil).Regexp())
+	assert.False(t, re.MatchString(""))
+	assert.False(t, re.MatchString("10.0.0.1"))
+}
@@ -0,0 +1,209 @@
+package cidr
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	ipv4ReverseSuffix = "in-addr.arpa."
+	ipv6ReverseSuffix = "ip6.arpa."
+)
+
+// reverseName returns the reverse DNS name of the first labels octets (IPv4) or nibbles (IPv6) of ip
+func reverseName(ip net.IP, labels int) string {
+	var sb strings.Builder
+	if len(ip) == net.IPv4len {
+		for i := labels - 1; i >= 0; i-- {
+			sb.WriteString(strconv.Itoa(int(ip[i])))
+			sb.WriteByte('.')
+		}
+		sb.WriteString(ipv4ReverseSuffix)
+		return sb.String()
+	}
+
+	const hexDigit = "0123456789abcdef"
+	for i := labels - 1; i >= 0; i-- {
+		if i%2 == 0 {
+			sb.WriteByte(hexDigit[ip[i/2]>>4])
+		} else {
+			sb.WriteByte(hexDigit[ip[i/2]&0x0F])
+		}
+		sb.WriteByte('.')
+	}
+	sb.WriteString(ipv6ReverseSuffix)
+	return sb.String()
+}
+
+// PTRName returns the fully qualified reverse DNS name of ip,
+// like "1.2.0.192.in-addr.arpa." or "1.0.0.0.[...].8.b.d.0.1.0.0.2.ip6.arpa."
+func PTRName(ip string) (string, error) {
+	ipObj := parseIP(ip)
+	if ipObj == nil {
+		return "", fmt.Errorf("invalid ip: %v", ip)
+	}
+	if len(ipObj) == net.IPv4len {
+		return reverseName(ipObj, net.IPv4len), nil
+	}
+	return reverseName(ipObj, net.IPv6len*2), nil
+}
+
+// ReverseZones returns the reverse DNS zones covering the CIDR.
+// 	Zones are octet-aligned for IPv4 and nibble-aligned for IPv6, so a CIDR which is not aligned is split,
+// for example, "192.168.0.0/23" results in "0.168.192.in-addr.arpa." and "1.168.192.in-addr.arpa.".
+// 	The host label is never part of a zone, so an IPv4 CIDR smaller than /24 results in its /24 zone
+// (see ClasslessDelegation), and likewise an IPv6 CIDR smaller than /124 results in its /124 zone.
+func (c CIDR) ReverseZones() []string {
+	ones, bits := c.ipNet.Mask.Size()
+	step := 4
+	if bits == 32 {
+		step = 8
+	}
+	zoneOnes := (ones + step - 1) / step * step
+	if zoneOnes > bits-step {
+		zoneOnes = bits - step
+	}
+	if zoneOnes <= ones {
+		return []string{reverseName(c.ipNet.IP.Mask(net.CIDRMask(zoneOnes, bits)), zoneOnes/step)}
+	}
+
+	// splits into at most 2^(step-1) zones, far below the subnet number limit
+	cs, _ := c.SubNetting(MethodSubnetMask, zoneOnes)
+	zones := make([]string, 0, len(cs))
+	for _, sub := range cs {
+		zones = append(zones, reverseName(sub.ipNet.IP, zoneOnes/step))
+	}
+	return zones
+}
+
+// splitReverseName splits a reverse DNS name into its labels, in the order they appear in the name,
+// and reports whether it is IPv4
+func splitReverseName(name string) (labels []string, isV4 bool, err error) {
+	s := strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+	switch {
+	case strings.HasSuffix(s, "."+ipv4ReverseSuffix) || s == ipv4ReverseSuffix:
+		s, isV4 = strings.TrimSuffix(s, ipv4ReverseSuffix), true
+	case strings.HasSuffix(s, "."+ipv6ReverseSuffix) || s == ipv6ReverseSuffix:
+		s = strings.TrimSuffix(s, ipv6ReverseSuffix)
+	default:
+		return nil, false, fmt.Errorf("not a reverse dns name: %v", name)
+	}
+	if s = strings.TrimSuffix(s, "."); s != "" {
+		labels = strings.Split(s, ".")
+	}
+	return labels, isV4, nil
+}
+
+// ParseReverseZone parses a reverse DNS zone name as the CIDR it represents,
+// like "1.168.192.in-addr.arpa." (192.168.1.0/24) or "8.b.d.0.1.0.0.2.ip6.arpa." (2001:db8::/32).
+// 	RFC 2317 classless zone names such as "64/26.1.168.192.in-addr.arpa." are supported as well.
+func ParseReverseZone(name string) (*CIDR, error) {
+	labels, isV4, err := splitReverseName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if isV4 {
+		if len(labels) > net.IPv4len {
+			return nil, fmt.Errorf("too many labels: %v", name)
+		}
+		ip := make(net.IP, net.IPv4len)
+		ones := len(labels) * 8
+		for i, label := range labels {
+			idx := len(labels) - i - 1
+			if i == 0 && len(labels) == net.IPv4len && strings.Contains(label, "/") {
+				// RFC 2317 classless label "<network>/<mask length>"
+				parts := strings.SplitN(label, "/", 2)
+				n, err := strconv.Atoi(parts[1])
+				if err != nil || n < 24 || n > 32 {
+					return nil, fmt.Errorf("invalid classless label: %v", label)
+				}
+				ones, label = n, parts[0]
+			}
+			n, err := strconv.Atoi(label)
+			if err != nil || n < 0 || n > 255 {
+				return nil, fmt.Errorf("invalid label: %v", label)
+			}
+			ip[idx] = byte(n)
+		}
+		c := newCIDR(ip, ones)
+		if !c.ipNet.IP.Equal(ip) {
+			return nil, fmt.Errorf("network is not aligned to mask: %v", name)
+		}
+		return c, nil
+	}
+
+	if len(labels) > net.IPv6len*2 {
+		return nil, fmt.Errorf("too many labels: %v", name)
+	}
+	ip := make(net.IP, net.IPv6len)
+	for i, label := range labels {
+		n, err := strconv.ParseUint(label, 16, 8)
+		if err != nil || len(label) != 1 {
+			return nil, fmt.Errorf("invalid label: %v", label)
+		}
+		idx := len(labels) - i - 1
+		if idx%2 == 0 {
+			ip[idx/2] |= byte(n) << 4
+		} else {
+			ip[idx/2] |= byte(n)
+		}
+	}
+	return newCIDR(ip, len(labels)*4), nil
+}
+
+// ParsePTRName parses a fully qualified reverse DNS name as the IP it represents,
+// like "1.2.0.192.in-addr.arpa." (192.0.2.1)
+func ParsePTRName(name string) (net.IP, error) {
+	labels, isV4, err := splitReverseName(name)
+	if err != nil {
+		return nil, err
+	}
+	if (isV4 && len(labels) != net.IPv4len) || (!isV4 && len(labels) != net.IPv6len*2) {
+		return nil, fmt.Errorf("not a complete ptr name: %v", name)
+	}
+	if isV4 && strings.Contains(labels[0], "/") {
+		return nil, fmt.Errorf("invalid label: %v", labels[0])
+	}
+	c, err := ParseReverseZone(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.ipNet.IP, nil
+}
+
+// CNAMERecord is a DNS CNAME resource record
+type CNAMERecord struct {
+	Name   string
+	Target string
+}
+
+// String returns the record in zone file format
+func (r CNAMERecord) String() string {
+	return r.Name + "\tIN\tCNAME\t" + r.Target
+}
+
+// ClasslessDelegation returns the RFC 2317 child zone of an IPv4 CIDR smaller than /24,
+// like "0/26.2.0.192.in-addr.arpa." for "192.0.2.0/26",
+// and the CNAME records the parent /24 zone publishes to delegate each IP of the CIDR to it.
+func (c CIDR) ClasslessDelegation() (zone string, records []CNAMERecord, err error) {
+	ones, bits := c.ipNet.Mask.Size()
+	if bits != 32 || ones <= 24 {
+		return "", nil, fmt.Errorf("classless delegation is only for IPv4 CIDR smaller than /24")
+	}
+
+	network := c.ipNet.IP.To4()
+	zone = fmt.Sprintf("%d/%d.%s", network[3], ones, reverseName(network, 3))
+	records = make([]CNAMERecord, 0, 1<<uint(bits-ones))
+	c.Each(func(ip string) bool {
+		ipObj := parseIP(ip)
+		records = append(records, CNAMERecord{
+			Name:   reverseName(ipObj, net.IPv4len),
+			Target: fmt.Sprintf("%d.%s", ipObj[3], zone),
+		})
+		return true
+	})
+	return zone, records, nil
+}
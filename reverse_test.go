@@ -0,0 +1,103 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPTRName(t *testing.T) {
+	name, err := PTRName("192.0.2.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0.192.in-addr.arpa.", name)
+
+	name, err = PTRName("2001:db8::567:89ab")
+	assert.NoError(t, err)
+	assert.Equal(t, "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", name)
+
+	_, err = PTRName("invalid")
+	assert.Error(t, err)
+}
+
+func TestParsePTRName(t *testing.T) {
+	ip, err := ParsePTRName("1.2.0.192.in-addr.arpa.")
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.1", ip.String())
+
+	ip, err = ParsePTRName("B.A.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.B.D.0.1.0.0.2.IP6.ARPA")
+	assert.NoError(t, err)
+	assert.Equal(t, "2001:db8::567:89ab", ip.String())
+
+	_, err = ParsePTRName("2.0.192.in-addr.arpa.")
+	assert.Error(t, err)
+	_, err = ParsePTRName("1.2.0.192.example.com.")
+	assert.Error(t, err)
+	_, err = ParsePTRName("256.2.0.192.in-addr.arpa.")
+	assert.Error(t, err)
+}
+
+func TestParseReverseZone(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect string
+	}{
+		{"1.168.192.in-addr.arpa.", "192.168.1.0/24"},
+		{"168.192.in-addr.arpa", "192.168.0.0/16"},
+		{"in-addr.arpa.", "0.0.0.0/0"},
+		{"64/26.1.168.192.in-addr.arpa.", "192.168.1.64/26"},
+		{"8.b.d.0.1.0.0.2.ip6.arpa.", "2001:db8::/32"},
+		{"0.8.b.d.0.1.0.0.2.ip6.arpa.", "2001:db8::/36"},
+	}
+	for _, test := range tests {
+		c, err := ParseReverseZone(test.name)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expect, c.String(), test.name)
+	}
+
+	_, err := ParseReverseZone("65/26.1.168.192.in-addr.arpa.")
+	assert.Error(t, err)
+	_, err = ParseReverseZone("10.8.b.d.0.1.0.0.2.ip6.arpa.")
+	assert.Error(t, err)
+}
+
+func TestCIDR_ReverseZones(t *testing.T) {
+	tests := []struct {
+		cidr   string
+		expect []string
+	}{
+		{"192.168.1.0/24", []string{"1.168.192.in-addr.arpa."}},
+		{"192.168.0.0/23", []string{"0.168.192.in-addr.arpa.", "1.168.192.in-addr.arpa."}},
+		{"192.168.1.64/26", []string{"1.168.192.in-addr.arpa."}},
+		{"10.0.0.0/8", []string{"10.in-addr.arpa."}},
+		{"0.0.0.0/0", []string{"in-addr.arpa."}},
+		{"2001:db8::/32", []string{"8.b.d.0.1.0.0.2.ip6.arpa."}},
+		{"2001:db8::/31", []string{"8.b.d.0.1.0.0.2.ip6.arpa.", "9.b.d.0.1.0.0.2.ip6.arpa."}},
+		{"2001:db8::1/128", []string{"0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expect, ParseNoError(test.cidr).ReverseZones(), test.cidr)
+	}
+
+	for _, zone := range ParseNoError("10.0.0.0/13").ReverseZones() {
+		c, err := ParseReverseZone(zone)
+		assert.NoError(t, err)
+		assert.Equal(t, true, ParseNoError("10.0.0.0/13").Contains(c.Network().String()))
+	}
+}
+
+func TestCIDR_ClasslessDelegation(t *testing.T) {
+	zone, records, err := ParseNoError("192.0.2.64/30").ClasslessDelegation()
+	assert.NoError(t, err)
+	assert.Equal(t, "64/30.2.0.192.in-addr.arpa.", zone)
+	assert.Equal(t, 4, len(records))
+	assert.Equal(t, CNAMERecord{Name: "65.2.0.192.in-addr.arpa.", Target: "65.64/30.2.0.192.in-addr.arpa."}, records[1])
+	assert.Equal(t, "65.2.0.192.in-addr.arpa.\tIN\tCNAME\t65.64/30.2.0.192.in-addr.arpa.", records[1].String())
+
+	c, err := ParseReverseZone(zone)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.64/30", c.String())
+
+	_, _, err = ParseNoError("192.0.2.0/24").ClasslessDelegation()
+	assert.Error(t, err)
+	_, _, err = ParseNoError("2001:db8::/126").ClasslessDelegation()
+	assert.Error(t, err)
+}
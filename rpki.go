@@ -0,0 +1,211 @@
+package cidr
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// VRP is a Validated ROA Payload, authorizing ASN to originate Prefix and its more specifics up to MaxLength.
+// 	An IPv4-mapped Prefix is validated as IPv4, and its MaxLength can be either the IPv4 or the IPv6 one.
+type VRP struct {
+	Prefix      *CIDR
+	MaxLength   int
+	ASN         uint32
+	TrustAnchor string
+}
+
+// parseASN parses an AS number like "AS13335" or "13335"
+func parseASN(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 2 && strings.EqualFold(s[:2], "AS") {
+		s = s[2:]
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid asn: %v", s)
+	}
+	return uint32(n), nil
+}
+
+// newVRP returns the VRP of the fields, maxLength defaults to the prefix length if empty
+func newVRP(asn, prefix, maxLength, ta string) (*VRP, error) {
+	v := &VRP{TrustAnchor: ta}
+	var err error
+	if v.ASN, err = parseASN(asn); err != nil {
+		return nil, err
+	}
+	if v.Prefix, err = Parse(strings.TrimSpace(prefix)); err != nil {
+		return nil, err
+	}
+	ip, ones := trieKey(v.Prefix)
+	v.MaxLength = ones
+	if maxLength = strings.TrimSpace(maxLength); maxLength != "" {
+		if v.MaxLength, err = strconv.Atoi(maxLength); err == nil {
+			v.MaxLength = vrpMaxLength(v)
+		}
+		if err != nil || v.MaxLength < ones || v.MaxLength > len(ip)*8 {
+			return nil, fmt.Errorf("invalid max length %v of %v", maxLength, prefix)
+		}
+	}
+	return v, nil
+}
+
+// vrpMaxLength returns the max length of v as IPv4 for an IPv4-mapped prefix,
+// like 24 for "::ffff:10.0.0.0/112" of max length 120 or 24
+func vrpMaxLength(v *VRP) int {
+	ip, _ := trieKey(v.Prefix)
+	if _, bits := v.Prefix.ipNet.Mask.Size(); len(ip)*8 < bits && v.MaxLength > len(ip)*8 {
+		return v.MaxLength - (bits - len(ip)*8)
+	}
+	return v.MaxLength
+}
+
+// ParseVRPJSON parses VRPs exported as JSON by RPKI validators like rpki-client or Routinator,
+// like {"roas": [{"asn": "AS13335", "prefix": "1.0.0.0/24", "maxLength": 24, "ta": "apnic"}]}.
+// 	The asn can be either a number or a string with or without the "AS" prefix.
+func ParseVRPJSON(r io.Reader) ([]*VRP, error) {
+	var doc struct {
+		ROAs []struct {
+			ASN       json.RawMessage `json:"asn"`
+			Prefix    string          `json:"prefix"`
+			MaxLength json.Number     `json:"maxLength"`
+			TA        string          `json:"ta"`
+		} `json:"roas"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	vrps := make([]*VRP, 0, len(doc.ROAs))
+	for i, roa := range doc.ROAs {
+		asn := strings.Trim(string(roa.ASN), `"`)
+		v, err := newVRP(asn, roa.Prefix, roa.MaxLength.String(), roa.TA)
+		if err != nil {
+			return nil, fmt.Errorf("roa %d: %v", i, err)
+		}
+		vrps = append(vrps, v)
+	}
+	return vrps, nil
+}
+
+// ParseVRPCSV parses VRPs exported as CSV by RPKI validators like Routinator,
+// with columns ASN, IP Prefix, Max Length and an optional Trust Anchor, like "AS13335,1.0.0.0/24,24,apnic".
+// 	A header line is skipped.
+func ParseVRPCSV(r io.Reader) ([]*VRP, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var vrps []*VRP
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return vrps, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: too few fields", line)
+		}
+		if line == 1 {
+			if _, err := parseASN(record[0]); err != nil {
+				continue
+			}
+		}
+		var ta string
+		if len(record) > 3 {
+			ta = record[3]
+		}
+		v, err := newVRP(record[0], record[1], record[2], ta)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		vrps = append(vrps, v)
+	}
+}
+
+// RPKIState is the route origin validation state of RFC 6811
+type RPKIState int
+
+const (
+	// RPKINotFound no VRP covers the route prefix
+	RPKINotFound = RPKIState(0)
+	// RPKIValid a covering VRP matches the route prefix length and origin AS
+	RPKIValid = RPKIState(1)
+	// RPKIInvalid the route prefix is covered by VRPs, but none of them matches
+	RPKIInvalid = RPKIState(2)
+)
+
+// String returns the name of the state as in RFC 6811
+func (s RPKIState) String() string {
+	switch s {
+	case RPKINotFound:
+		return "NotFound"
+	case RPKIValid:
+		return "Valid"
+	case RPKIInvalid:
+		return "Invalid"
+	}
+	return fmt.Sprintf("RPKIState(%d)", int(s))
+}
+
+// ROATable validates route origins against VRPs, see RFC 6811
+type ROATable struct {
+	trie *Trie
+	size int
+}
+
+// NewROATable returns a ROATable of vrps
+func NewROATable(vrps []*VRP) *ROATable {
+	t := &ROATable{trie: NewTrie()}
+	for _, v := range vrps {
+		t.Add(v)
+	}
+	return t
+}
+
+// Len returns the number of VRPs in the table
+func (t *ROATable) Len() int {
+	return t.size
+}
+
+// Add adds a VRP to the table
+func (t *ROATable) Add(v *VRP) {
+	var arr []*VRP
+	if value, ok := t.trie.Get(v.Prefix); ok {
+		arr = value.([]*VRP)
+	}
+	t.trie.Insert(v.Prefix, append(arr, v))
+	t.size++
+}
+
+// Covering returns the VRPs whose prefix covers prefix, from the least specific to the most specific
+func (t *ROATable) Covering(prefix *CIDR) []*VRP {
+	var vrps []*VRP
+	t.trie.Covering(prefix, func(c *CIDR, value interface{}) bool {
+		vrps = append(vrps, value.([]*VRP)...)
+		return true
+	})
+	return vrps
+}
+
+// Validate returns the validation state of a route of prefix originated by originASN, as defined by RFC 6811.
+// 	Use originASN 0 for a route whose origin can not be determined (AS_PATH ending with an AS_SET),
+// which is never Valid, as well as VRPs of AS 0 never match any route.
+// IPv4-mapped prefixes are validated as IPv4.
+func (t *ROATable) Validate(prefix *CIDR, originASN uint32) RPKIState {
+	_, ones := trieKey(prefix)
+	state := RPKINotFound
+	for _, v := range t.Covering(prefix) {
+		state = RPKIInvalid
+		if originASN != 0 && v.ASN == originASN && ones <= vrpMaxLength(v) {
+			return RPKIValid
+		}
+	}
+	return state
+}
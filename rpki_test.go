@@ -0,0 +1,101 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseVRPJSON(t *testing.T) {
+	vrps, err := ParseVRPJSON(strings.NewReader(`{
+  "metadata": {"generated": 1700000000},
+  "roas": [
+    {"asn": "AS13335", "prefix": "1.0.0.0/24", "maxLength": 24, "ta": "apnic"},
+    {"asn": 64496, "prefix": "2001:db8::/32", "maxLength": 48, "ta": "ripe"},
+    {"asn": "64497", "prefix": "192.0.2.0/24"}
+  ]
+}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(vrps))
+	assert.Equal(t, VRP{Prefix: ParseNoError("1.0.0.0/24"), MaxLength: 24, ASN: 13335, TrustAnchor: "apnic"}, *vrps[0])
+	assert.Equal(t, uint32(64496), vrps[1].ASN)
+	assert.Equal(t, 48, vrps[1].MaxLength)
+	assert.Equal(t, 24, vrps[2].MaxLength)
+
+	_, err = ParseVRPJSON(strings.NewReader(`{"roas": [{"asn": "AS1", "prefix": "1.0.0.0/24", "maxLength": 16}]}`))
+	assert.Error(t, err)
+	_, err = ParseVRPJSON(strings.NewReader(`{"roas": [{"asn": "ASX", "prefix": "1.0.0.0/24"}]}`))
+	assert.Error(t, err)
+}
+
+func TestParseVRPCSV(t *testing.T) {
+	vrps, err := ParseVRPCSV(strings.NewReader(`ASN,IP Prefix,Max Length,Trust Anchor
+AS13335,1.0.0.0/24,24,apnic
+AS64496,2001:db8::/32,48,ripe
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(vrps))
+	assert.Equal(t, "2001:db8::/32", vrps[1].Prefix.String())
+	assert.Equal(t, "ripe", vrps[1].TrustAnchor)
+
+	vrps, err = ParseVRPCSV(strings.NewReader("13335,1.0.0.0/24,24\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(vrps))
+
+	// the max length of an IPv4-mapped prefix is IPv4, in either notation
+	vrps, err = ParseVRPCSV(strings.NewReader("AS1,::ffff:10.0.0.0/112,24\nAS1,::ffff:10.0.0.0/112,120\nAS1,::ffff:10.0.0.0/112,\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{24, 24, 16}, []int{vrps[0].MaxLength, vrps[1].MaxLength, vrps[2].MaxLength})
+	}
+	_, err = ParseVRPCSV(strings.NewReader("AS1,::ffff:10.0.0.0/112,33\n"))
+	assert.Error(t, err)
+
+	_, err = ParseVRPCSV(strings.NewReader("ASN,IP Prefix,Max Length\nAS1,1.0.0.0/24,33\n"))
+	assert.Error(t, err)
+	assert.Equal(t, true, strings.HasPrefix(err.Error(), "line 2:"))
+}
+
+func TestROATable_Validate(t *testing.T) {
+	table := NewROATable([]*VRP{
+		{Prefix: ParseNoError("10.0.0.0/16"), MaxLength: 20, ASN: 64496},
+		{Prefix: ParseNoError("10.0.0.0/24"), MaxLength: 24, ASN: 64497},
+		{Prefix: ParseNoError("192.0.2.0/24"), MaxLength: 24, ASN: 0},
+		{Prefix: ParseNoError("2001:db8::/32"), MaxLength: 48, ASN: 64498},
+		{Prefix: ParseNoError("::ffff:198.18.0.0/111"), MaxLength: 120, ASN: 64499},
+	})
+	assert.Equal(t, 5, table.Len())
+
+	tests := []struct {
+		prefix string
+		asn    uint32
+		expect RPKIState
+	}{
+		{"10.0.0.0/16", 64496, RPKIValid},
+		{"10.0.16.0/20", 64496, RPKIValid},
+		{"10.0.0.0/21", 64496, RPKIInvalid}, // too specific
+		{"10.0.0.0/24", 64497, RPKIValid},
+		{"10.0.0.0/24", 64496, RPKIInvalid},
+		{"10.0.0.0/16", 64499, RPKIInvalid},
+		{"10.0.0.0/8", 64496, RPKINotFound},
+		{"172.16.0.0/12", 64496, RPKINotFound},
+		{"192.0.2.0/24", 0, RPKIInvalid}, // AS0
+		{"2001:db8:1::/48", 64498, RPKIValid},
+		{"2001:db8:1::/64", 64498, RPKIInvalid},
+		{"10.0.0.0/16", 0, RPKIInvalid},
+		// IPv4-mapped prefixes are validated as IPv4
+		{"::ffff:10.0.0.0/120", 64497, RPKIValid},
+		{"::ffff:10.0.0.0/112", 64496, RPKIValid},
+		{"::ffff:10.0.0.0/117", 64496, RPKIInvalid},
+		{"198.18.1.0/24", 64499, RPKIValid},
+		{"198.18.1.0/25", 64499, RPKIInvalid},
+		{"::ffff:198.18.1.0/121", 64499, RPKIInvalid},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expect, table.Validate(ParseNoError(test.prefix), test.asn), test.prefix)
+	}
+
+	assert.Equal(t, 2, len(table.Covering(ParseNoError("10.0.0.0/25"))))
+	assert.Equal(t, "Valid", RPKIValid.String())
+	assert.Equal(t, "NotFound", RPKINotFound.String())
+	assert.Equal(t, "Invalid", RPKIInvalid.String())
+}
@@ -0,0 +1,52 @@
+package cidr
+
+import (
+	"net"
+	"strings"
+)
+
+// RouteList is a list of routes, see SplitTunnel
+type RouteList []*CIDR
+
+// SplitTunnel returns the minimal list of routes covering include except exclude, in ascending order,
+// like routing everything through a VPN except the LAN and the VPN endpoint.
+// 	An empty include is the universe, 0.0.0.0/0 and ::/0.
+func SplitTunnel(include, exclude []*CIDR) RouteList {
+	if len(include) == 0 {
+		include = anyCIDRs
+	}
+	return rangesToCIDRs(subtractRanges(cidrRanges(include), cidrRanges(exclude)))
+}
+
+// Strings returns the routes in CIDR notation
+func (l RouteList) Strings() []string {
+	arr := make([]string, 0, len(l))
+	for _, c := range l {
+		arr = append(arr, c.String())
+	}
+	return arr
+}
+
+// String returns the routes in CIDR notation, one per line
+func (l RouteList) String() string {
+	return strings.Join(l.Strings(), "\n")
+}
+
+// AllowedIPs returns the routes as a WireGuard AllowedIPs line, like "AllowedIPs = 0.0.0.0/5, 8.0.0.0/7"
+func (l RouteList) AllowedIPs() string {
+	return "AllowedIPs = " + strings.Join(l.Strings(), ", ")
+}
+
+// OpenVPN returns the routes as OpenVPN directives, one per line,
+// like "route 0.0.0.0 248.0.0.0" for IPv4 and "route-ipv6 2000::/3" for IPv6
+func (l RouteList) OpenVPN() string {
+	arr := make([]string, 0, len(l))
+	for _, c := range l {
+		if c.IsIPv4() {
+			arr = append(arr, "route "+c.Network().String()+" "+net.IP(c.Mask()).String())
+		} else {
+			arr = append(arr, "route-ipv6 "+c.String())
+		}
+	}
+	return strings.Join(arr, "\n")
+}
@@ -0,0 +1,35 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSplitTunnel(t *testing.T) {
+	routes := SplitTunnel(nil, []*CIDR{
+		ParseNoError("192.168.0.0/16"),
+		ParseNoError("198.51.100.7/32"),
+		ParseNoError("fd00::/8"),
+	})
+	assert.Equal(t, []string{
+		"0.0.0.0/1", "128.0.0.0/2", "192.0.0.0/9", "192.128.0.0/11", "192.160.0.0/13", "192.169.0.0/16",
+		"192.170.0.0/15", "192.172.0.0/14", "192.176.0.0/12", "192.192.0.0/10", "193.0.0.0/8", "194.0.0.0/7",
+		"196.0.0.0/7", "198.0.0.0/11", "198.32.0.0/12", "198.48.0.0/15", "198.50.0.0/16", "198.51.0.0/18",
+		"198.51.64.0/19", "198.51.96.0/22", "198.51.100.0/30", "198.51.100.4/31", "198.51.100.6/32",
+		"198.51.100.8/29", "198.51.100.16/28", "198.51.100.32/27", "198.51.100.64/26", "198.51.100.128/25",
+		"198.51.101.0/24", "198.51.102.0/23", "198.51.104.0/21", "198.51.112.0/20", "198.51.128.0/17",
+		"198.52.0.0/14", "198.56.0.0/13", "198.64.0.0/10", "198.128.0.0/9", "199.0.0.0/8", "200.0.0.0/5",
+		"208.0.0.0/4", "224.0.0.0/3",
+		"::/1", "8000::/2", "c000::/3", "e000::/4", "f000::/5", "f800::/6", "fc00::/8", "fe00::/7",
+	}, routes.Strings())
+
+	routes = SplitTunnel([]*CIDR{ParseNoError("10.0.0.0/8"), ParseNoError("2001:db8::/32")},
+		[]*CIDR{ParseNoError("10.0.0.0/9"), ParseNoError("2001:db8:8000::/33")})
+	assert.Equal(t, "10.128.0.0/9\n2001:db8::/33", routes.String())
+	assert.Equal(t, "AllowedIPs = 10.128.0.0/9, 2001:db8::/33", routes.AllowedIPs())
+	assert.Equal(t, "route 10.128.0.0 255.128.0.0\nroute-ipv6 2001:db8::/33", routes.OpenVPN())
+
+	routes = SplitTunnel([]*CIDR{ParseNoError("10.0.0.0/8")}, []*CIDR{ParseNoError("10.0.0.0/8")})
+	assert.Equal(t, 0, len(routes))
+	assert.Equal(t, "AllowedIPs = ", routes.AllowedIPs())
+}
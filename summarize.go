@@ -0,0 +1,106 @@
+package cidr
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// SummaryLevel is the candidate summary of components at a prefix length
+type SummaryLevel struct {
+	// Ones is the prefix length of the summary routes, components more specific than it are summarized,
+	// less specific ones are kept as they are
+	Ones      int
+	Summaries []*CIDR
+	// Extra is the address space covered by the summaries but not owned by any component,
+	// which would be blackholed if advertised
+	Extra      []*CIDR
+	ExtraCount *big.Int
+}
+
+// SummaryReport is the summarization of component routes, see Summarize
+type SummaryReport struct {
+	Components []*CIDR
+	// Exact is the minimal list of summary routes covering exactly the components, see Aggregate
+	Exact []*CIDR
+	// Levels are the candidate summaries from the single summary covering all components
+	// to the prefix length of the most specific component
+	Levels []SummaryLevel
+}
+
+// commonPrefixLen returns the length of the common prefix of a and b, which are of the same length
+func commonPrefixLen(a, b net.IP) int {
+	for i := 0; i < len(a)*8; i++ {
+		if ipBit(a, i) != ipBit(b, i) {
+			return i
+		}
+	}
+	return len(a) * 8
+}
+
+// Summarize reports the summary routes which can be advertised for components, the component routes of
+// an area for example, at each prefix length, and the extra address space each level would cover.
+// 	Unlike SuperNetting, components can be of any mask and are not required to be contiguous,
+// but must be of the same family.
+func Summarize(components []*CIDR) (*SummaryReport, error) {
+	if len(components) == 0 {
+		return nil, fmt.Errorf("no component")
+	}
+
+	sorted := make([]*CIDR, len(components))
+	copy(sorted, components)
+	SortCIDRAsc(sorted)
+
+	first, _ := trieKey(sorted[0])
+	common, maxOnes := len(first)*8, 0
+	for _, c := range sorted {
+		ip, ones := trieKey(c)
+		if len(ip) != len(first) {
+			return nil, fmt.Errorf("not the same family")
+		}
+		if n := commonPrefixLen(first, ip); n < common {
+			common = n
+		}
+		if ones < common {
+			common = ones
+		}
+		if ones > maxOnes {
+			maxOnes = ones
+		}
+	}
+
+	owned := cidrRanges(sorted)
+	report := &SummaryReport{Components: sorted, Exact: rangesToCIDRs(owned)}
+	for level := common; level <= maxOnes; level++ {
+		var summaries []*CIDR
+		var last *CIDR
+		for _, c := range sorted {
+			ip, ones := trieKey(c)
+			if ones > level {
+				ones = level
+			}
+			// sorted by network then mask, so a covering summary always comes first
+			if last != nil {
+				lastOnes, _ := last.ipNet.Mask.Size()
+				if lastOnes <= ones && last.ipNet.Contains(ip) {
+					continue
+				}
+			}
+			last = newCIDR(ip, ones)
+			summaries = append(summaries, last)
+		}
+
+		extra := subtractRanges(cidrRanges(summaries), owned)
+		count := big.NewInt(0)
+		for _, r := range extra {
+			count.Add(count, r.IPCount())
+		}
+		report.Levels = append(report.Levels, SummaryLevel{
+			Ones:       level,
+			Summaries:  summaries,
+			Extra:      rangesToCIDRs(extra),
+			ExtraCount: count,
+		})
+	}
+	return report, nil
+}
@@ -0,0 +1,59 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	report, err := Summarize([]*CIDR{
+		ParseNoError("10.1.3.0/24"),
+		ParseNoError("10.1.0.0/24"),
+		ParseNoError("10.1.1.0/24"),
+		ParseNoError("10.1.4.0/25"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.1.0.0/24", "10.1.1.0/24", "10.1.3.0/24", "10.1.4.0/25"}, cidrStrings(report.Components))
+	assert.Equal(t, []string{"10.1.0.0/23", "10.1.3.0/24", "10.1.4.0/25"}, cidrStrings(report.Exact))
+	assert.Equal(t, 5, len(report.Levels))
+
+	level := report.Levels[0]
+	assert.Equal(t, 21, level.Ones)
+	assert.Equal(t, []string{"10.1.0.0/21"}, cidrStrings(level.Summaries))
+	assert.Equal(t, []string{"10.1.2.0/24", "10.1.4.128/25", "10.1.5.0/24", "10.1.6.0/23"}, cidrStrings(level.Extra))
+	assert.Equal(t, int64(1152), level.ExtraCount.Int64())
+
+	level = report.Levels[1]
+	assert.Equal(t, 22, level.Ones)
+	assert.Equal(t, []string{"10.1.0.0/22", "10.1.4.0/22"}, cidrStrings(level.Summaries))
+
+	level = report.Levels[3]
+	assert.Equal(t, 24, level.Ones)
+	assert.Equal(t, []string{"10.1.0.0/24", "10.1.1.0/24", "10.1.3.0/24", "10.1.4.0/24"}, cidrStrings(level.Summaries))
+	assert.Equal(t, []string{"10.1.4.128/25"}, cidrStrings(level.Extra))
+
+	level = report.Levels[4]
+	assert.Equal(t, 25, level.Ones)
+	assert.Equal(t, []string{"10.1.0.0/24", "10.1.1.0/24", "10.1.3.0/24", "10.1.4.0/25"}, cidrStrings(level.Summaries))
+	assert.Equal(t, int64(0), level.ExtraCount.Int64())
+
+	report, err = Summarize([]*CIDR{ParseNoError("2001:db8::/48"), ParseNoError("2001:db8:1::/48"), ParseNoError("2001:db8::/32")})
+	assert.NoError(t, err)
+	assert.Equal(t, 32, report.Levels[0].Ones)
+	assert.Equal(t, []string{"2001:db8::/32"}, cidrStrings(report.Levels[len(report.Levels)-1].Summaries))
+	assert.Equal(t, []string{"2001:db8::/32"}, cidrStrings(report.Exact))
+
+	// IPv4-mapped components are summarized as IPv4
+	report, err = Summarize([]*CIDR{ParseNoError("::ffff:10.1.0.0/120"), ParseNoError("10.1.1.0/24")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(report.Levels))
+	assert.Equal(t, 23, report.Levels[0].Ones)
+	assert.Equal(t, []string{"10.1.0.0/23"}, cidrStrings(report.Levels[0].Summaries))
+	assert.Equal(t, []string{"10.1.0.0/24", "10.1.1.0/24"}, cidrStrings(report.Levels[1].Summaries))
+	assert.Equal(t, []string{"10.1.0.0/23"}, cidrStrings(report.Exact))
+
+	_, err = Summarize(nil)
+	assert.Error(t, err)
+	_, err = Summarize([]*CIDR{ParseNoError("10.0.0.0/8"), ParseNoError("2001:db8::/32")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package cidr
+
+import "sort"
+
+const (
+	TagProvider = "provider"
+	TagRegion   = "region"
+	TagService  = "service"
+)
+
+// Tags are key-value annotations of a prefix, like {"provider": "aws", "region": "us-east-1"}
+type Tags map[string]string
+
+// TaggedPrefix is a CIDR annotated with tags
+type TaggedPrefix struct {
+	CIDR *CIDR
+	Tags Tags
+}
+
+// TaggedSet is a set of tagged prefixes, answering which tags own an IP,
+// and exporting the aggregated CIDRs of a tag.
+// 	The same CIDR can be added many times with different tags.
+type TaggedSet struct {
+	trie *Trie
+}
+
+// NewTaggedSet returns an empty TaggedSet
+func NewTaggedSet() *TaggedSet {
+	return &TaggedSet{trie: NewTrie()}
+}
+
+// Add adds c with tags to the set
+func (s *TaggedSet) Add(c *CIDR, tags Tags) {
+	var arr []Tags
+	if v, ok := s.trie.Get(c); ok {
+		arr = v.([]Tags)
+	}
+	s.trie.Insert(c, append(arr, tags))
+}
+
+// AddPrefixes adds all prefixes to the set
+func (s *TaggedSet) AddPrefixes(ps []*TaggedPrefix) {
+	for _, p := range ps {
+		s.Add(p.CIDR, p.Tags)
+	}
+}
+
+// Lookup returns the tagged prefixes including ip, from the most specific to the least specific
+func (s *TaggedSet) Lookup(ip string) []*TaggedPrefix {
+	ipObj := parseIP(ip)
+	if ipObj == nil {
+		return nil
+	}
+	c := newCIDR(ipObj, len(ipObj)*8)
+
+	// Covering is from the least specific, while tags of the same CIDR keep the order they were added
+	var groups [][]*TaggedPrefix
+	s.trie.Covering(c, func(c *CIDR, value interface{}) bool {
+		var group []*TaggedPrefix
+		for _, tags := range value.([]Tags) {
+			group = append(group, &TaggedPrefix{CIDR: c, Tags: tags})
+		}
+		groups = append(groups, group)
+		return true
+	})
+	var result []*TaggedPrefix
+	for i := len(groups) - 1; i >= 0; i-- {
+		result = append(result, groups[i]...)
+	}
+	return result
+}
+
+// CIDRs returns the aggregated CIDRs tagged with key=value, see Aggregate
+func (s *TaggedSet) CIDRs(key, value string) []*CIDR {
+	var cs []*CIDR
+	s.trie.Each(func(c *CIDR, v interface{}) bool {
+		for _, tags := range v.([]Tags) {
+			if tv, ok := tags[key]; ok && tv == value {
+				cs = append(cs, c)
+				break
+			}
+		}
+		return true
+	})
+	return Aggregate(cs)
+}
+
+// GroupBy returns the aggregated CIDRs grouped by the value of tag key,
+// prefixes without tag key are ignored
+func (s *TaggedSet) GroupBy(key string) map[string][]*CIDR {
+	groups := make(map[string][]*CIDR)
+	s.trie.Each(func(c *CIDR, v interface{}) bool {
+		seen := make(map[string]bool)
+		for _, tags := range v.([]Tags) {
+			if tv, ok := tags[key]; ok && !seen[tv] {
+				seen[tv] = true
+				groups[tv] = append(groups[tv], c)
+			}
+		}
+		return true
+	})
+	for k, cs := range groups {
+		groups[k] = Aggregate(cs)
+	}
+	return groups
+}
+
+// TagValues returns the distinct values of tag key in ascending order
+func (s *TaggedSet) TagValues(key string) []string {
+	seen := make(map[string]bool)
+	s.trie.Each(func(c *CIDR, v interface{}) bool {
+		for _, tags := range v.([]Tags) {
+			if tv, ok := tags[key]; ok {
+				seen[tv] = true
+			}
+		}
+		return true
+	})
+	values := make([]string, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,30 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTaggedSet(t *testing.T) {
+	s := NewTaggedSet()
+	s.Add(ParseNoError("10.0.0.0/16"), Tags{TagService: "AMAZON", TagRegion: "us-east-1"})
+	s.Add(ParseNoError("10.0.0.0/24"), Tags{TagService: "EC2", TagRegion: "us-east-1"})
+	s.Add(ParseNoError("10.0.0.0/24"), Tags{TagService: "AMAZON", TagRegion: "us-east-1"})
+	s.Add(ParseNoError("10.0.1.0/24"), Tags{TagService: "EC2", TagRegion: "us-east-1"})
+	s.Add(ParseNoError("2001:db8::/32"), Tags{TagService: "EC2", TagRegion: "eu-west-1"})
+
+	ps := s.Lookup("10.0.0.1")
+	assert.Equal(t, 3, len(ps))
+	assert.Equal(t, "10.0.0.0/24", ps[0].CIDR.String())
+	assert.Equal(t, "EC2", ps[0].Tags[TagService])
+	assert.Equal(t, "10.0.0.0/16", ps[2].CIDR.String())
+	assert.Equal(t, 0, len(s.Lookup("10.1.0.1")))
+	assert.Equal(t, 0, len(s.Lookup("invalid")))
+	assert.Equal(t, "eu-west-1", s.Lookup("2001:db8::1")[0].Tags[TagRegion])
+
+	assert.Equal(t, []string{"10.0.0.0/23", "2001:db8::/32"}, cidrStrings(s.CIDRs(TagService, "EC2")))
+	groups := s.GroupBy(TagRegion)
+	assert.Equal(t, []string{"10.0.0.0/16"}, cidrStrings(groups["us-east-1"]))
+	assert.Equal(t, []string{"2001:db8::/32"}, cidrStrings(groups["eu-west-1"]))
+	assert.Equal(t, []string{"AMAZON", "EC2"}, s.TagValues(TagService))
+}
@@ -0,0 +1,214 @@
+package cidr
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// TextMatch is an IP or a CIDR found in text
+type TextMatch struct {
+	// Text is the match as in the text, like "10[.]0[.]0[.]1" if defanged
+	Text string
+	// IP is the IP of the match, the IP as written for a CIDR
+	IP net.IP
+	// CIDR is the CIDR of the match, the host CIDR like "10.0.0.1/32" for an IP
+	CIDR *CIDR
+	// IsCIDR reports whether the match is written as a CIDR
+	IsCIDR bool
+	// Line and Column are 1-based, Column and Offset are in bytes
+	Line   int
+	Column int
+	Offset int64
+}
+
+// TextScanOptions are the options of EachTextIP
+type TextScanOptions struct {
+	// Defanged recognizes defanged notations, like "10[.]0[.]0[.]1", "10(.)0(.)0(.)1", "10[dot]0[dot]0[dot]1"
+	// and "2001[:]db8[:][:]1"
+	Defanged bool
+	// Dedup reports only the first match of each IP or CIDR
+	Dedup bool
+}
+
+// defangedNotations are the defanged notations and their replacements
+var defangedNotations = []struct {
+	notation string
+	char     byte
+}{
+	{"[.]", '.'}, {"(.)", '.'}, {"{.}", '.'}, {"[dot]", '.'}, {"(dot)", '.'}, {"[:]", ':'},
+}
+
+// textIPv4Pattern matches dotted quads, with an optional prefix length, within invalid tokens
+var textIPv4Pattern = regexp.MustCompile(`\d{1,3}(?:\.\d{1,3}){3}(?:/\d{1,2})?`)
+
+// textLine is a line of text, normalized if defanged, with the positions of each normalized byte in the line
+type textLine struct {
+	raw        string
+	text       string
+	start, end []int
+}
+
+func newTextLine(raw string, defanged bool) *textLine {
+	l := &textLine{raw: raw, text: raw}
+	if !defanged {
+		return l
+	}
+	var sb strings.Builder
+	for i := 0; i < len(raw); {
+		n, c := 1, raw[i]
+		for _, d := range defangedNotations {
+			if len(raw)-i >= len(d.notation) && strings.EqualFold(raw[i:i+len(d.notation)], d.notation) {
+				n, c = len(d.notation), d.char
+				break
+			}
+		}
+		sb.WriteByte(c)
+		l.start = append(l.start, i)
+		l.end = append(l.end, i+n)
+		i += n
+	}
+	l.text = sb.String()
+	return l
+}
+
+// pos returns the position in the line of the normalized bytes from i to j
+func (l *textLine) pos(i, j int) (int, int) {
+	if l.start == nil {
+		return i, j
+	}
+	return l.start[i], l.end[j-1]
+}
+
+func isTextTokenChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' || c == ':' || c == '.' || c == '/'
+}
+
+func isTextWordChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+// parseTextToken parses a token as a CIDR or an IP
+func parseTextToken(token string) (*TextMatch, bool) {
+	if pos := strings.IndexByte(token, '/'); pos != -1 {
+		c, err := Parse(token)
+		if err != nil {
+			return nil, false
+		}
+		return &TextMatch{IP: parseIP(token[:pos]), CIDR: c, IsCIDR: true}, true
+	}
+	ip := parseIP(token)
+	if ip == nil {
+		return nil, false
+	}
+	return &TextMatch{IP: ip, CIDR: newCIDR(ip, len(ip)*8)}, true
+}
+
+// scan finds the matches of the line, in order of position, and reports whether the iteration should continue
+func (l *textLine) scan(iterator func(m *TextMatch, i, j int) bool) bool {
+	text := l.text
+	for i := 0; i < len(text); {
+		if !isTextTokenChar(text[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(text) && isTextTokenChar(text[j]) {
+			j++
+		}
+		start, end := i, j
+		i = j
+
+		// trim the punctuation around, but not the "::" of IPv6
+		for start < end && (text[start] == '.' || text[start] == '/' ||
+			(text[start] == ':' && !strings.HasPrefix(text[start:end], "::"))) {
+			start++
+		}
+		for end > start && (text[end-1] == '.' || text[end-1] == '/' ||
+			(text[end-1] == ':' && !strings.HasSuffix(text[start:end], "::"))) {
+			end--
+		}
+		// a lone "::", like in "ns :: f", is punctuation, "::/0" is a CIDR
+		if start == end || !strings.ContainsAny(text[start:end], ".:") || text[start:end] == "::" {
+			continue
+		}
+		// a token within a word, like "std::string", is not an IP
+		if (start > 0 && isTextWordChar(text[start-1])) || (end < len(text) && isTextWordChar(text[end])) {
+			continue
+		}
+
+		if m, ok := parseTextToken(text[start:end]); ok {
+			if !iterator(m, start, end) {
+				return false
+			}
+			continue
+		}
+		// dotted quads within an invalid token, like "192.0.2.1:8080"
+		token := text[start:end]
+		for _, loc := range textIPv4Pattern.FindAllStringIndex(token, -1) {
+			a, b := loc[0], loc[1]
+			if (a > 0 && (token[a-1] == '.' || isTextWordChar(token[a-1]))) ||
+				(b < len(token) && (token[b] == '.' || token[b] == '/' || isTextWordChar(token[b]))) {
+				continue
+			}
+			if m, ok := parseTextToken(token[a:b]); ok {
+				if !iterator(m, start+a, start+b) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// EachTextIP iterates over the IPv4, IPv6 and CIDR tokens found in free-form text, like logs or tickets,
+// reading r line by line.
+// 	IPv6 can be compressed, bracketed like "[2001:db8::1]:443" or with a zone like "fe80::1%eth0",
+// IPv4 can be followed by a port like "192.0.2.1:8080". Tokens within words, like "std::string",
+// and invalid addresses, like "999.1.1.1", versions like "1.2.3.4.5" or MAC addresses, are skipped.
+func EachTextIP(r io.Reader, opts TextScanOptions, iterator func(m *TextMatch) bool) error {
+	reader := bufio.NewReader(r)
+	seen := map[string]bool{}
+	var offset int64
+	for lineNo := 1; ; lineNo++ {
+		raw, err := reader.ReadString('\n')
+		if len(raw) != 0 {
+			line := newTextLine(strings.TrimRight(raw, "\r\n"), opts.Defanged)
+			ok := line.scan(func(m *TextMatch, i, j int) bool {
+				if opts.Dedup {
+					key := m.CIDR.String()
+					if seen[key] {
+						return true
+					}
+					seen[key] = true
+				}
+				start, end := line.pos(i, j)
+				m.Text = line.raw[start:end]
+				m.Line, m.Column, m.Offset = lineNo, start+1, offset+int64(start)
+				return iterator(m)
+			})
+			if !ok {
+				return nil
+			}
+			offset += int64(len(raw))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ScanTextIPs returns the IPs and CIDRs found in free-form text, see EachTextIP
+func ScanTextIPs(r io.Reader, opts TextScanOptions) ([]*TextMatch, error) {
+	var arr []*TextMatch
+	err := EachTextIP(r, opts, func(m *TextMatch) bool {
+		arr = append(arr, m)
+		return true
+	})
+	return arr, err
+}
@@ -0,0 +1,83 @@
+package cidr
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func textMatchStrings(ms []*TextMatch) []string {
+	arr := make([]string, 0, len(ms))
+	for _, m := range ms {
+		arr = append(arr, fmt.Sprintf("%d:%d %s %s", m.Line, m.Column, m.Text, m.CIDR))
+	}
+	return arr
+}
+
+func TestScanTextIPs(t *testing.T) {
+	text := "Jan 1 sshd: failed login from 192.0.2.10 port 22.\r\n" +
+		"blocked 198.51.100.0/24, [2001:db8::1]:443 and fe80::1%eth0.\n" +
+		"proxy=203.0.113.5:8080 ::ffff:192.0.2.1 ::1\n" +
+		"noise: 999.1.1.1 1.2.3.4.5 v1.2.3.4 std::string 12:30:45 00:1a:2b:3c:4d:5e 2024/01/02 cafe:: dead::beef: a :: b ::/0\n" +
+		"again 192.0.2.10"
+	ms, err := ScanTextIPs(strings.NewReader(text), TextScanOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"1:31 192.0.2.10 192.0.2.10/32",
+		"2:9 198.51.100.0/24 198.51.100.0/24",
+		"2:27 2001:db8::1 2001:db8::1/128",
+		"2:48 fe80::1 fe80::1/128",
+		"3:7 203.0.113.5 203.0.113.5/32",
+		"3:24 ::ffff:192.0.2.1 192.0.2.1/32",
+		"3:41 ::1 ::1/128",
+		"4:87 cafe:: cafe::/128",
+		"4:94 dead::beef dead::beef/128",
+		"4:113 ::/0 ::/0",
+		"5:7 192.0.2.10 192.0.2.10/32",
+	}, textMatchStrings(ms))
+	assert.True(t, ms[1].IsCIDR)
+	assert.False(t, ms[0].IsCIDR)
+	assert.Equal(t, int64(len("Jan 1 sshd: failed login from ")), ms[0].Offset)
+	assert.Equal(t, int64(strings.Index(text, "again 192")+6), ms[len(ms)-1].Offset)
+
+	ms, err = ScanTextIPs(strings.NewReader(text), TextScanOptions{Dedup: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, len(ms))
+
+	// into a set
+	var cs []*CIDR
+	for _, m := range ms {
+		cs = append(cs, m.CIDR)
+	}
+	set := NewIPSet(cs)
+	assert.True(t, set.Contains("198.51.100.7"))
+}
+
+func TestScanTextIPs_Defanged(t *testing.T) {
+	text := "IOC: 10[.]0[.]0[.]1, 192(.)0(.)2(.)0/24 and 203[DOT]0[dot]113[.]9\n2001[:]db8[:][:]1 plain 10.0.0.1"
+	ms, err := ScanTextIPs(strings.NewReader(text), TextScanOptions{Defanged: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"1:6 10[.]0[.]0[.]1 10.0.0.1/32",
+		"1:22 192(.)0(.)2(.)0/24 192.0.2.0/24",
+		"1:45 203[DOT]0[dot]113[.]9 203.0.113.9/32",
+		"2:1 2001[:]db8[:][:]1 2001:db8::1/128",
+		"2:25 10.0.0.1 10.0.0.1/32",
+	}, textMatchStrings(ms))
+	assert.Equal(t, int64(strings.Index(text, "2001")), ms[3].Offset)
+
+	ms, err = ScanTextIPs(strings.NewReader(text), TextScanOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2:25 10.0.0.1 10.0.0.1/32"}, textMatchStrings(ms))
+}
+
+func TestEachTextIP_Stop(t *testing.T) {
+	n := 0
+	err := EachTextIP(strings.NewReader("10.0.0.1 10.0.0.2\n10.0.0.3"), TextScanOptions{}, func(m *TextMatch) bool {
+		n++
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,190 @@
+package cidr
+
+import "net"
+
+// Trie is a binary prefix trie mapping CIDRs to values, IPv4 and IPv6 are kept apart.
+// 	It supports longest prefix match and finding the CIDRs covering or covered by a CIDR.
+// Trie is not safe for concurrent use while being modified.
+type Trie struct {
+	v4   *trieNode
+	v6   *trieNode
+	size int
+}
+
+type trieNode struct {
+	children [2]*trieNode
+	cidr     *CIDR
+	value    interface{}
+}
+
+// NewTrie returns an empty Trie
+func NewTrie() *Trie {
+	return &Trie{v4: &trieNode{}, v6: &trieNode{}}
+}
+
+// Len returns the number of CIDRs in the Trie
+func (t *Trie) Len() int {
+	return t.size
+}
+
+// trieKey returns the normalized network address and mask length of c,
+// the mask length of an IPv4-mapped CIDR is the IPv4 one, like 8 for "::ffff:10.0.0.0/104"
+func trieKey(c *CIDR) (net.IP, int) {
+	ones, bits := c.ipNet.Mask.Size()
+	ip := normalizeIP(c.ipNet.IP)
+	if len(ip) == net.IPv4len && bits == 8*net.IPv6len {
+		if ones < 8*(net.IPv6len-net.IPv4len) {
+			return c.ipNet.IP.To16(), ones
+		}
+		ones -= 8 * (net.IPv6len - net.IPv4len)
+	}
+	return ip, ones
+}
+
+func (t *Trie) root(ip net.IP) *trieNode {
+	if len(ip) == net.IPv4len {
+		return t.v4
+	}
+	return t.v6
+}
+
+// Insert adds c with value to the Trie, the value of an existing c is replaced
+func (t *Trie) Insert(c *CIDR, value interface{}) {
+	ip, ones := trieKey(c)
+	node := t.root(ip)
+	for i := 0; i < ones; i++ {
+		b := ipBit(ip, i)
+		if node.children[b] == nil {
+			node.children[b] = &trieNode{}
+		}
+		node = node.children[b]
+	}
+	if node.cidr == nil {
+		node.cidr = newCIDR(ip, ones)
+		t.size++
+	}
+	node.value = value
+}
+
+// find returns the node of c, or nil if not exists
+func (t *Trie) find(c *CIDR) *trieNode {
+	ip, ones := trieKey(c)
+	node := t.root(ip)
+	for i := 0; i < ones && node != nil; i++ {
+		node = node.children[ipBit(ip, i)]
+	}
+	return node
+}
+
+// Get returns the value of c, and whether c exists in the Trie
+func (t *Trie) Get(c *CIDR) (interface{}, bool) {
+	node := t.find(c)
+	if node == nil || node.cidr == nil {
+		return nil, false
+	}
+	return node.value, true
+}
+
+// Delete removes c from the Trie and reports whether it existed
+func (t *Trie) Delete(c *CIDR) bool {
+	ip, ones := trieKey(c)
+	path := make([]*trieNode, 0, ones+1)
+	node := t.root(ip)
+	for i := 0; i < ones && node != nil; i++ {
+		path = append(path, node)
+		node = node.children[ipBit(ip, i)]
+	}
+	if node == nil || node.cidr == nil {
+		return false
+	}
+	node.cidr, node.value = nil, nil
+	t.size--
+
+	// prune the nodes which no longer lead to any CIDR
+	for i := len(path) - 1; i >= 0; i-- {
+		if node.cidr != nil || node.children[0] != nil || node.children[1] != nil {
+			break
+		}
+		path[i].children[ipBit(ip, i)] = nil
+		node = path[i]
+	}
+	return true
+}
+
+// Lookup returns the most specific CIDR including ip and its value
+func (t *Trie) Lookup(ip string) (*CIDR, interface{}, bool) {
+	ipObj := parseIP(ip)
+	if ipObj == nil {
+		return nil, nil, false
+	}
+	var found *trieNode
+	t.eachCovering(ipObj, len(ipObj)*8, func(node *trieNode) bool {
+		found = node
+		return true
+	})
+	if found == nil {
+		return nil, nil, false
+	}
+	return found.cidr, found.value, true
+}
+
+// contains reports whether any CIDR in the Trie includes ip, which must be normalized
+func (t *Trie) contains(ip net.IP) bool {
+	found := false
+	t.eachCovering(ip, len(ip)*8, func(node *trieNode) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// eachCovering iterates over the nodes holding a CIDR which covers the first ones bits of ip,
+// from the least specific to the most specific
+func (t *Trie) eachCovering(ip net.IP, ones int, iterator func(node *trieNode) bool) {
+	node := t.root(ip)
+	for i := 0; node != nil; i++ {
+		if node.cidr != nil && !iterator(node) {
+			return
+		}
+		if i >= ones {
+			return
+		}
+		node = node.children[ipBit(ip, i)]
+	}
+}
+
+// Covering iterates over the CIDRs in the Trie which include c (c itself included),
+// from the least specific to the most specific
+func (t *Trie) Covering(c *CIDR, iterator func(c *CIDR, value interface{}) bool) {
+	ip, ones := trieKey(c)
+	t.eachCovering(ip, ones, func(node *trieNode) bool {
+		return iterator(node.cidr, node.value)
+	})
+}
+
+// Covered iterates over the CIDRs in the Trie which are included in c (c itself included), in ascending order
+func (t *Trie) Covered(c *CIDR, iterator func(c *CIDR, value interface{}) bool) {
+	if node := t.find(c); node != nil {
+		node.walk(iterator)
+	}
+}
+
+// Each iterates over all CIDRs in the Trie in ascending order, IPv4 first
+func (t *Trie) Each(iterator func(c *CIDR, value interface{}) bool) {
+	if t.v4.walk(iterator) {
+		t.v6.walk(iterator)
+	}
+}
+
+// walk iterates over the subtree in pre-order, and reports whether the iteration should continue
+func (n *trieNode) walk(iterator func(c *CIDR, value interface{}) bool) bool {
+	if n.cidr != nil && !iterator(n.cidr, n.value) {
+		return false
+	}
+	for _, child := range n.children {
+		if child != nil && !child.walk(iterator) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package cidr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	trie := NewTrie()
+	for i, s := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "192.168.1.0/24", "2001:db8::/32", "0.0.0.0/0"} {
+		trie.Insert(ParseNoError(s), i)
+	}
+	assert.Equal(t, 6, trie.Len())
+
+	c, v, ok := trie.Lookup("10.1.2.3")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "10.1.2.0/24", c.String())
+	assert.Equal(t, 2, v)
+
+	c, _, _ = trie.Lookup("10.2.0.1")
+	assert.Equal(t, "10.0.0.0/8", c.String())
+	c, _, _ = trie.Lookup("172.16.0.1")
+	assert.Equal(t, "0.0.0.0/0", c.String())
+	c, _, _ = trie.Lookup("2001:db8::1")
+	assert.Equal(t, "2001:db8::/32", c.String())
+	_, _, ok = trie.Lookup("2001:db9::1")
+	assert.Equal(t, false, ok)
+
+	v, ok = trie.Get(ParseNoError("10.1.0.0/16"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, v)
+	_, ok = trie.Get(ParseNoError("10.1.0.0/17"))
+	assert.Equal(t, false, ok)
+
+	var arr []string
+	trie.Covering(ParseNoError("10.1.2.128/25"), func(c *CIDR, v interface{}) bool {
+		arr = append(arr, c.String())
+		return true
+	})
+	assert.Equal(t, []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"}, arr)
+
+	arr = arr[:0]
+	trie.Covered(ParseNoError("10.0.0.0/8"), func(c *CIDR, v interface{}) bool {
+		arr = append(arr, c.String())
+		return true
+	})
+	assert.Equal(t, []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"}, arr)
+
+	arr = arr[:0]
+	trie.Each(func(c *CIDR, v interface{}) bool {
+		arr = append(arr, c.String())
+		return true
+	})
+	assert.Equal(t, []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "192.168.1.0/24", "2001:db8::/32"}, arr)
+
+	assert.Equal(t, true, trie.Delete(ParseNoError("10.1.2.0/24")))
+	assert.Equal(t, false, trie.Delete(ParseNoError("10.1.2.0/24")))
+	assert.Equal(t, 5, trie.Len())
+	c, _, _ = trie.Lookup("10.1.2.3")
+	assert.Equal(t, "10.1.0.0/16", c.String())
+
+	// nodes are pruned once no CIDR is left below
+	for _, s := range []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "192.168.1.0/24"} {
+		assert.Equal(t, true, trie.Delete(ParseNoError(s)))
+	}
+	assert.Equal(t, [2]*trieNode{}, trie.v4.children)
+	assert.Equal(t, 1, trie.Len())
+}
+
+func TestTrie_IPv4Mapped(t *testing.T) {
+	trie := NewTrie()
+	trie.Insert(ParseNoError("::ffff:10.0.0.0/104"), 1)
+	trie.Insert(ParseNoError("::ffff:0.0.0.0/96"), 0)
+	assert.Equal(t, 2, trie.Len())
+
+	c, v, ok := trie.Lookup("10.1.2.3")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "10.0.0.0/8", c.String())
+	assert.Equal(t, 1, v)
+	c, _, _ = trie.Lookup("172.16.0.1")
+	assert.Equal(t, "0.0.0.0/0", c.String())
+
+	v, ok = trie.Get(ParseNoError("10.0.0.0/8"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, v)
+}
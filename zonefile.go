@@ -0,0 +1,149 @@
+package cidr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// SOA is the start of authority record of a DNS zone
+type SOA struct {
+	MName   string // primary name server, like "ns1.example.net."
+	RName   string // mailbox of the person responsible for the zone, like "hostmaster.example.net."
+	Serial  uint32
+	Refresh uint32
+	Retry   uint32
+	Expire  uint32
+	Minimum uint32
+}
+
+// ZoneOptions specifies the header of a generated zone file
+type ZoneOptions struct {
+	// Origin of the zone, defaults to the reverse zone covering the CIDR (see CIDR.ReverseZones).
+	// 	An RFC 2317 classless zone like "64/26.1.168.192.in-addr.arpa." is supported,
+	// in which case records are named "<last octet>.<origin>".
+	Origin string
+	// TTL is the default TTL of the zone, the $TTL directive is omitted if zero
+	TTL uint32
+	// SOA record of the zone, omitted if nil
+	SOA *SOA
+	// NS names of the name servers of the zone
+	NS []string
+}
+
+// PTRFunc returns the host name for ip, or false if ip has no PTR record
+type PTRFunc func(ip net.IP) (string, bool)
+
+// PTRMap returns a PTRFunc looking up host names from m, whose keys are IPs
+func PTRMap(m map[string]string) PTRFunc {
+	names := make(map[string]string, len(m))
+	for k, v := range m {
+		if ip := parseIP(k); ip != nil {
+			names[ip.String()] = v
+		}
+	}
+	return func(ip net.IP) (string, bool) {
+		name, ok := names[ip.String()]
+		return name, ok
+	}
+}
+
+// PTRTemplate returns a PTRFunc generating host names from tmpl, like "ip-{dash}.example.net.".
+// 	The supported placeholders are:
+//	- {ip}, the IP, like "192.168.1.5" or "2001:db8::5"
+//	- {dash}, the IP with separators replaced by dashes, like "192-168-1-5",
+//	  IPv6 is expanded to avoid empty labels, like "2001-0db8-0000-0000-0000-0000-0000-0005"
+//	- {a}, {b}, {c}, {d}, the octets of an IPv4 address
+func PTRTemplate(tmpl string) PTRFunc {
+	return func(ip net.IP) (string, bool) {
+		ip = normalizeIP(ip)
+		if ip == nil {
+			return "", false
+		}
+		var dash string
+		pairs := []string{"{ip}", ip.String()}
+		if len(ip) == net.IPv4len {
+			dash = strings.Replace(ip.String(), ".", "-", -1)
+			for i, key := range []string{"{a}", "{b}", "{c}", "{d}"} {
+				pairs = append(pairs, key, strconv.Itoa(int(ip[i])))
+			}
+		} else {
+			hextets := make([]string, 0, 8)
+			for i := 0; i < net.IPv6len; i += 2 {
+				hextets = append(hextets, fmt.Sprintf("%02x%02x", ip[i], ip[i+1]))
+			}
+			dash = strings.Join(hextets, "-")
+		}
+		pairs = append(pairs, "{dash}", dash)
+		return strings.NewReplacer(pairs...).Replace(tmpl), true
+	}
+}
+
+// fqdn returns name with a trailing dot
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// WritePTRZone writes a BIND format zone file with a PTR record for every IP in the CIDR
+// which hostname returns a name for.
+// 	IPs are streamed one by one with Each, so it is suitable for large CIDRs.
+// Host names without a trailing dot are made fully qualified.
+func (c CIDR) WritePTRZone(w io.Writer, hostname PTRFunc, opts ZoneOptions) error {
+	origin := opts.Origin
+	if origin == "" {
+		zones := c.ReverseZones()
+		if len(zones) != 1 {
+			return fmt.Errorf("cidr spans %d reverse zones, split it or specify the origin", len(zones))
+		}
+		origin = zones[0]
+	}
+	origin = strings.ToLower(fqdn(origin))
+
+	zone, err := ParseReverseZone(origin)
+	if err != nil {
+		return err
+	}
+	zoneOnes, _ := zone.ipNet.Mask.Size()
+	ones, _ := c.ipNet.Mask.Size()
+	if zone.IsIPv4() != c.IsIPv4() || ones < zoneOnes || !zone.ipNet.Contains(c.ipNet.IP) {
+		return fmt.Errorf("cidr %v is out of zone %v", c.String(), origin)
+	}
+	classless := zone.IsIPv4() && zoneOnes > 24
+
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(bw, "$ORIGIN %s\n", origin)
+	if opts.TTL > 0 {
+		_, _ = fmt.Fprintf(bw, "$TTL %d\n", opts.TTL)
+	}
+	if soa := opts.SOA; soa != nil {
+		_, _ = fmt.Fprintf(bw, "@\tIN\tSOA\t%s %s (%d %d %d %d %d)\n",
+			fqdn(soa.MName), fqdn(soa.RName), soa.Serial, soa.Refresh, soa.Retry, soa.Expire, soa.Minimum)
+	}
+	for _, ns := range opts.NS {
+		_, _ = fmt.Fprintf(bw, "@\tIN\tNS\t%s\n", fqdn(ns))
+	}
+
+	c.Each(func(ip string) bool {
+		ipObj := parseIP(ip)
+		name, ok := hostname(ipObj)
+		if !ok || name == "" {
+			return true
+		}
+		var owner string
+		if classless {
+			owner = strconv.Itoa(int(ipObj[3]))
+		} else {
+			owner, _ = PTRName(ip)
+			owner = strings.TrimSuffix(owner, "."+origin)
+		}
+		_, err = fmt.Fprintf(bw, "%s\tIN\tPTR\t%s\n", owner, fqdn(name))
+		return err == nil
+	})
+	return bw.Flush()
+}
@@ -0,0 +1,63 @@
+package cidr
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestPTRTemplate(t *testing.T) {
+	fn := PTRTemplate("ip-{dash}.example.net.")
+	name, ok := fn(net.ParseIP("192.168.1.5"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "ip-192-168-1-5.example.net.", name)
+
+	name, _ = fn(net.ParseIP("2001:db8::5"))
+	assert.Equal(t, "ip-2001-0db8-0000-0000-0000-0000-0000-0005.example.net.", name)
+
+	name, _ = PTRTemplate("host{d}.{c}.example.net")(net.ParseIP("10.0.3.7"))
+	assert.Equal(t, "host7.3.example.net", name)
+}
+
+func TestCIDR_WritePTRZone(t *testing.T) {
+	var buf bytes.Buffer
+	err := ParseNoError("192.168.1.0/30").WritePTRZone(&buf, PTRTemplate("ip-{dash}.example.net"), ZoneOptions{
+		TTL: 3600,
+		SOA: &SOA{MName: "ns1.example.net", RName: "hostmaster.example.net.", Serial: 1, Refresh: 7200, Retry: 900, Expire: 1209600, Minimum: 300},
+		NS:  []string{"ns1.example.net.", "ns2.example.net."},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"$ORIGIN 1.168.192.in-addr.arpa.",
+		"$TTL 3600",
+		"@\tIN\tSOA\tns1.example.net. hostmaster.example.net. (1 7200 900 1209600 300)",
+		"@\tIN\tNS\tns1.example.net.",
+		"@\tIN\tNS\tns2.example.net.",
+		"0\tIN\tPTR\tip-192-168-1-0.example.net.",
+		"1\tIN\tPTR\tip-192-168-1-1.example.net.",
+		"2\tIN\tPTR\tip-192-168-1-2.example.net.",
+		"3\tIN\tPTR\tip-192-168-1-3.example.net.",
+		"",
+	}, "\n"), buf.String())
+
+	buf.Reset()
+	err = ParseNoError("192.168.1.64/30").WritePTRZone(&buf, PTRMap(map[string]string{
+		"192.168.1.65": "gw.example.net.",
+	}), ZoneOptions{Origin: "64/30.1.168.192.in-addr.arpa."})
+	assert.NoError(t, err)
+	assert.Equal(t, "$ORIGIN 64/30.1.168.192.in-addr.arpa.\n65\tIN\tPTR\tgw.example.net.\n", buf.String())
+
+	buf.Reset()
+	err = ParseNoError("2001:db8::/126").WritePTRZone(&buf, PTRMap(map[string]string{
+		"2001:db8::1": "gw.example.net.",
+	}), ZoneOptions{Origin: "8.b.d.0.1.0.0.2.ip6.arpa."})
+	assert.NoError(t, err)
+	assert.Equal(t, "$ORIGIN 8.b.d.0.1.0.0.2.ip6.arpa.\n1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0\tIN\tPTR\tgw.example.net.\n", buf.String())
+
+	err = ParseNoError("192.168.0.0/23").WritePTRZone(&buf, PTRTemplate("{a}"), ZoneOptions{})
+	assert.Error(t, err)
+	err = ParseNoError("192.168.2.0/24").WritePTRZone(&buf, PTRTemplate("{a}"), ZoneOptions{Origin: "1.168.192.in-addr.arpa."})
+	assert.Error(t, err)
+}